@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	apiKeyHeader = "X-API-Key"
+	bearerPrefix = "Bearer "
+
+	algHS256 = "HS256"
+	algRS256 = "RS256"
+
+	// INFO: permission which allows every operation.
+	permissionAll = "*"
+
+	// INFO: allowed clock difference between token issuer and the server.
+	jwtLeeway = 30 * time.Second
+)
+
+var (
+	errUnauthenticated   = errors.New("authentication required, use \"X-API-Key\" header or bearer token")
+	errInvalidAPIKey     = errors.New("invalid API key")
+	errInvalidToken      = errors.New("invalid bearer token")
+	errTokenExpired      = errors.New("bearer token is expired or not valid yet")
+	errNoSubject         = errors.New("bearer token has no \"sub\" claim")
+	errUnsupportedAlg    = errors.New("unsupported token algorithm, should be one of \"HS256\", \"RS256\"")
+	errUnknownSigningKey = errors.New("unknown token signing key")
+	errForbidden         = errors.New("operation is not allowed for this credential")
+	errInvalidJWKS       = errors.New("invalid JWKS, should contain RSA keys with \"n\" and \"e\"")
+)
+
+// INFO: APIKey is static credential, permissions are names of allowed end-points without leading slash, e.g.
+// "sum", "sparse/matmul", "jobs", or "*" for all of them.
+type APIKey struct {
+	Key         string   `json:"key"`
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+// INFO: principal is authenticated client of the request.
+type principal struct {
+	name        string
+	permissions map[string]bool
+}
+
+func newPrincipal(name string, permissions []string) principal {
+	p := principal{name: name, permissions: make(map[string]bool, len(permissions))}
+	for _, perm := range permissions {
+		p.permissions[strings.Trim(strings.TrimSpace(perm), "/")] = true
+	}
+	return p
+}
+
+func (p principal) allowed(operation string) bool {
+	return p.permissions[permissionAll] || p.permissions[operation]
+}
+
+type principalContextKey struct{}
+
+func principalFrom(ctx context.Context) (principal, bool) {
+	p, ok := ctx.Value(principalContextKey{}).(principal)
+	return p, ok
+}
+
+// INFO: routes served without credentials, API description should be readable before the client has any.
+var publicRoutes = map[string]bool{openAPIPath: true}
+
+// INFO: operation of the route pattern used in permissions, e.g. "/jobs/" -> "jobs".
+func routeOperation(pattern string) string {
+	return strings.Trim(pattern, "/")
+}
+
+// INFO: authenticator checks API keys and JWT bearer tokens signed with HMAC secret (HS256) or RSA keys from
+// JWKS (RS256). Keys are kept as hashes, so lookups don't depend on key bytes.
+type authenticator struct {
+	apiKeys    map[[sha256.Size]byte]principal
+	hmacSecret []byte
+	rsaKeys    map[string]*rsa.PublicKey
+	now        func() time.Time
+}
+
+func newAuthenticator() *authenticator {
+	return &authenticator{apiKeys: map[[sha256.Size]byte]principal{}, rsaKeys: map[string]*rsa.PublicKey{}, now: time.Now}
+}
+
+func (a *authenticator) addAPIKeys(keys []APIKey) {
+	for _, key := range keys {
+		name := key.Name
+		if name == "" {
+			name = "key-" + fmt.Sprintf("%x", sha256.Sum256([]byte(key.Key)))[:8]
+		}
+		a.apiKeys[sha256.Sum256([]byte(key.Key))] = newPrincipal(name, key.Permissions)
+	}
+}
+
+// INFO: reads JSON list of API keys.
+func LoadAPIKeys(path string) ([]APIKey, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var keys []APIKey
+	err = json.Unmarshal(content, &keys)
+	if err != nil {
+		return nil, fmt.Errorf("reading API keys %s: %w", path, err)
+	}
+	return keys, nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// INFO: reads RSA signing keys from JWKS file by their "kid", keys of other types are skipped.
+func LoadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	err = json.Unmarshal(content, &set)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errInvalidJWKS, err.Error())
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Alg != "" && k.Alg != algRS256) || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil || len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, errInvalidJWKS
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	return keys, nil
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// INFO: claims used by the service, permissions are taken from "permissions" list or space separated "scope".
+type jwtClaims struct {
+	Subject     string   `json:"sub"`
+	ExpiresAt   *int64   `json:"exp"`
+	NotBefore   *int64   `json:"nbf"`
+	Permissions []string `json:"permissions"`
+	Scope       string   `json:"scope"`
+}
+
+func decodeSegment(segment string, v any) error {
+	content, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errInvalidToken
+	}
+	if json.Unmarshal(content, v) != nil {
+		return errInvalidToken
+	}
+	return nil
+}
+
+func (a *authenticator) verifySignature(header jwtHeader, signed string, signature []byte) error {
+	switch header.Alg {
+	case algHS256:
+		if len(a.hmacSecret) == 0 {
+			return errUnknownSigningKey
+		}
+		mac := hmac.New(sha256.New, a.hmacSecret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return errInvalidToken
+		}
+		return nil
+	case algRS256:
+		key, ok := a.rsaKeys[header.Kid]
+		if !ok {
+			return errUnknownSigningKey
+		}
+		digest := sha256.Sum256([]byte(signed))
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) != nil {
+			return errInvalidToken
+		}
+		return nil
+	default:
+		return errUnsupportedAlg
+	}
+}
+
+// INFO: validates compact JWT: signature, "exp", "nbf" and "sub" claims. Subject is required, it identifies
+// the client in rate limits and ownership of jobs.
+func (a *authenticator) parseToken(token string) (principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return principal{}, errInvalidToken
+	}
+	var header jwtHeader
+	err := decodeSegment(parts[0], &header)
+	if err != nil {
+		return principal{}, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return principal{}, errInvalidToken
+	}
+	err = a.verifySignature(header, parts[0]+"."+parts[1], signature)
+	if err != nil {
+		return principal{}, err
+	}
+
+	var claims jwtClaims
+	err = decodeSegment(parts[1], &claims)
+	if err != nil {
+		return principal{}, err
+	}
+	now := a.now()
+	if claims.ExpiresAt != nil && !now.Before(time.Unix(*claims.ExpiresAt, 0).Add(jwtLeeway)) {
+		return principal{}, errTokenExpired
+	}
+	if claims.NotBefore != nil && now.Add(jwtLeeway).Before(time.Unix(*claims.NotBefore, 0)) {
+		return principal{}, errTokenExpired
+	}
+	if strings.TrimSpace(claims.Subject) == "" {
+		return principal{}, errNoSubject
+	}
+
+	permissions := append(claims.Permissions, strings.Fields(claims.Scope)...)
+	return newPrincipal(claims.Subject, permissions), nil
+}
+
+// INFO: finds principal of request credentials, API key header has priority over bearer token.
+func (a *authenticator) authenticate(r *http.Request) (principal, error) {
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		p, ok := a.apiKeys[sha256.Sum256([]byte(key))]
+		if !ok {
+			return principal{}, errInvalidAPIKey
+		}
+		return p, nil
+	}
+	if auth := r.Header.Get("Authorization"); len(auth) > len(bearerPrefix) &&
+		strings.EqualFold(auth[:len(bearerPrefix)], bearerPrefix) {
+		return a.parseToken(strings.TrimSpace(auth[len(bearerPrefix):]))
+	}
+	return principal{}, errUnauthenticated
+}
+
+type authError struct {
+	Error string `json:"error"`
+}
+
+// INFO: checks credentials and permission for the operation. Requests of jobs keep principal of the client
+// who submitted the job, so only permission is checked for them. Failed attempts take tokens of the client IP
+// address from limit, once they are exhausted requests of the address are rejected before credentials are
+// checked, so credentials can't be brute forced. limit is nil when rate limit isn't configured.
+func (a *authenticator) middleware(operation string, limit *rateLimiter, log *zap.Logger) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := principalFrom(r.Context())
+			if !ok {
+				key := ipKey(r)
+				if limit != nil {
+					if exhausted, wait := limit.exhausted(key); exhausted {
+						rejectRateLimited(w, wait)
+						return
+					}
+				}
+				var err error
+				p, err = a.authenticate(r)
+				if err != nil {
+					if limit != nil {
+						_, _ = limit.allow(key)
+					}
+					log.Info("authentication failed", zap.String("operation", operation), zap.Error(err))
+					w.Header().Set("WWW-Authenticate", `Bearer realm="matrix"`)
+					_ = writeJSON(w, http.StatusUnauthorized, authError{Error: err.Error()})
+					return
+				}
+				r = r.WithContext(context.WithValue(r.Context(), principalContextKey{}, p))
+			}
+			if !p.allowed(operation) {
+				log.Info("operation forbidden", zap.String("operation", operation), zap.String("principal", p.name))
+				_ = writeJSON(w, http.StatusForbidden, authError{Error: fmt.Sprintf("%s: %q", errForbidden.Error(), operation)})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const (
+	keysPath   = "./data/keys.json"
+	testSecret = "secret"
+	testKid    = "test-key"
+)
+
+// INFO: builds compact JWT, key is HMAC secret for HS256 and RSA private key for RS256.
+func signToken(t *testing.T, alg string, kid string, key any, claims map[string]any) string {
+	t.Helper()
+	header, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	assert.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	assert.NoError(t, err)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	var signature []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signed))
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		assert.NoError(t, err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func writeJWKS(t *testing.T, key *rsa.PublicKey) string {
+	t.Helper()
+	jwks := map[string][]map[string]string{"keys": {
+		{"kty": "EC", "kid": "skipped", "crv": "P-256"},
+		{
+			"kty": "RSA",
+			"kid": testKid,
+			"alg": algRS256,
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		},
+	}}
+	content, err := json.Marshal(jwks)
+	assert.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	assert.NoError(t, os.WriteFile(path, content, 0o600))
+	return path
+}
+
+func Test_LoadAPIKeys(t *testing.T) {
+	keys, err := LoadAPIKeys(keysPath)
+	assert.NoError(t, err)
+	assert.Equal(t, []APIKey{
+		{Key: "dashboard-key", Name: "dashboard", Permissions: []string{"sum", "multiply", "invert"}},
+		{Key: "admin-key", Name: "admin", Permissions: []string{"*"}},
+	}, keys)
+
+	_, err = LoadAPIKeys(validPath)
+	assert.Error(t, err)
+}
+
+func Test_LoadJWKS(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	keys, err := LoadJWKS(writeJWKS(t, &private.PublicKey))
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+	assert.True(t, private.PublicKey.Equal(keys[testKid]))
+
+	invalidPath := filepath.Join(t.TempDir(), "invalid.json")
+	assert.NoError(t, os.WriteFile(invalidPath, []byte(`{"keys": [{"kty": "RSA", "n": "!", "e": "AQAB"}]}`), 0o600))
+	_, err = LoadJWKS(invalidPath)
+	assert.ErrorIs(t, err, errInvalidJWKS)
+}
+
+func Test_authenticator_parseToken(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	a := newAuthenticator()
+	a.now = func() time.Time { return now }
+	a.hmacSecret = []byte(testSecret)
+	a.rsaKeys[testKid] = &private.PublicKey
+
+	claims := map[string]any{"sub": "reporter", "exp": now.Add(time.Hour).Unix(), "scope": "sum invert"}
+	expected := newPrincipal("reporter", []string{"sum", "invert"})
+
+	tt := []struct {
+		name           string
+		providedToken  string
+		expectedResult principal
+		expectedErr    error
+	}{
+		{
+			name:          "fail: not a jwt",
+			providedToken: "abc.def",
+			expectedErr:   errInvalidToken,
+		},
+		{
+			name:          "fail: unsigned token",
+			providedToken: signToken(t, "none", "", nil, claims),
+			expectedErr:   errUnsupportedAlg,
+		},
+		{
+			name:          "fail: wrong hmac secret",
+			providedToken: signToken(t, algHS256, "", []byte("other"), claims),
+			expectedErr:   errInvalidToken,
+		},
+		{
+			name:          "fail: unknown kid",
+			providedToken: signToken(t, algRS256, "other", private, claims),
+			expectedErr:   errUnknownSigningKey,
+		},
+		{
+			name:          "fail: signed with other rsa key",
+			providedToken: signToken(t, algRS256, testKid, other, claims),
+			expectedErr:   errInvalidToken,
+		},
+		{
+			name:          "fail: expired",
+			providedToken: signToken(t, algHS256, "", []byte(testSecret), map[string]any{"exp": now.Add(-time.Minute).Unix()}),
+			expectedErr:   errTokenExpired,
+		},
+		{
+			name:          "fail: not valid yet",
+			providedToken: signToken(t, algHS256, "", []byte(testSecret), map[string]any{"nbf": now.Add(time.Minute).Unix()}),
+			expectedErr:   errTokenExpired,
+		},
+		{
+			name:          "fail: no subject",
+			providedToken: signToken(t, algHS256, "", []byte(testSecret), map[string]any{"exp": now.Add(time.Hour).Unix()}),
+			expectedErr:   errNoSubject,
+		},
+		{
+			name:           "success: hs256",
+			providedToken:  signToken(t, algHS256, "", []byte(testSecret), claims),
+			expectedResult: expected,
+		},
+		{
+			name:           "success: rs256",
+			providedToken:  signToken(t, algRS256, testKid, private, claims),
+			expectedResult: expected,
+		},
+		{
+			name: "success: permissions claim within leeway",
+			providedToken: signToken(t, algHS256, "", []byte(testSecret),
+				map[string]any{"sub": "admin", "exp": now.Add(-jwtLeeway / 2).Unix(), "permissions": []string{"*"}}),
+			expectedResult: newPrincipal("admin", []string{"*"}),
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := a.parseToken(tc.providedToken)
+			assert.Equal(t, tc.expectedResult, res)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestRouter_auth(t *testing.T) {
+	logger, err := zap.NewProduction()
+	assert.NoError(t, err)
+	keys, err := LoadAPIKeys(keysPath)
+	assert.NoError(t, err)
+	keys = append(keys, APIKey{Key: "jobs-key", Name: "scheduler", Permissions: []string{"jobs", "sum"}})
+	router, err := NewRouter(logger, WithAPIKeys(keys...), WithJWTSecret([]byte(testSecret)))
+	assert.NoError(t, err)
+	router.InitRoutes()
+	defer router.Close()
+
+	token := signToken(t, algHS256, "", []byte(testSecret), map[string]any{"sub": "reporter", "scope": "invert"})
+
+	tt := []struct {
+		name          string
+		providedPath  string
+		providedKey   string
+		providedToken string
+		expectedCode  int
+		expectedBody  string
+	}{
+		{
+			name:         "fail: no credentials - Unauthorized",
+			providedPath: sum,
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: fmt.Sprintf("{\"error\":%q}\n", errUnauthenticated.Error()),
+		},
+		{
+			name:         "fail: unknown api key - Unauthorized",
+			providedPath: sum,
+			providedKey:  "unknown",
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: fmt.Sprintf("{\"error\":%q}\n", errInvalidAPIKey.Error()),
+		},
+		{
+			name:         "fail: operation isn't permitted - Forbidden",
+			providedPath: decompose,
+			providedKey:  "dashboard-key",
+			expectedCode: http.StatusForbidden,
+			expectedBody: fmt.Sprintf("{\"error\":%q}\n", errForbidden.Error()+`: "decompose"`),
+		},
+		{
+			name:          "fail: token scope - Forbidden",
+			providedPath:  sum,
+			providedToken: token,
+			expectedCode:  http.StatusForbidden,
+			expectedBody:  fmt.Sprintf("{\"error\":%q}\n", errForbidden.Error()+`: "sum"`),
+		},
+		{
+			name:         "fail: jobs aren't permitted - Forbidden",
+			providedPath: jobs + "?op=sum",
+			providedKey:  "dashboard-key",
+			expectedCode: http.StatusForbidden,
+			expectedBody: fmt.Sprintf("{\"error\":%q}\n", errForbidden.Error()+`: "jobs"`),
+		},
+		{
+			name:         "fail: job of not permitted operation - Forbidden",
+			providedPath: jobs + "?op=decompose",
+			providedKey:  "jobs-key",
+			expectedCode: http.StatusForbidden,
+			expectedBody: fmt.Sprintf("{\"error\":%q}\n", errForbidden.Error()+`: "decompose"`),
+		},
+		{
+			name:         "success: api key",
+			providedPath: sum,
+			providedKey:  "dashboard-key",
+			expectedCode: http.StatusOK,
+			expectedBody: "45\n",
+		},
+		{
+			name:          "success: bearer token",
+			providedPath:  invert,
+			providedToken: token,
+			expectedCode:  http.StatusOK,
+			expectedBody:  "1,4,7\n2,5,8\n3,6,9\n",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := createFilesReq(testURL+tc.providedPath, formFile{key: fileKey, path: validPath})
+			assert.NoError(t, err)
+			if tc.providedKey != "" {
+				req.Header.Set(apiKeyHeader, tc.providedKey)
+			}
+			if tc.providedToken != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.providedToken)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+			if tc.expectedBody != "" {
+				assert.Equal(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+
+	// INFO: job request keeps client of submission, so permission of the operation is checked without
+	// credentials.
+	req, err := createFilesReq(testURL+jobs+"?op=sum", formFile{key: fileKey, path: validPath})
+	assert.NoError(t, err)
+	req.Header.Set(apiKeyHeader, "jobs-key")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusAccepted, w.Result().StatusCode)
+	var job Job
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&job))
+	job = waitJob(t, router.jobs, job.ID)
+	assert.Equal(t, jobDone, job.Status)
+	assert.Equal(t, "45\n", string(job.Result.Body))
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+)
+
+const (
+	opsKey = "ops"
+)
+
+var (
+	errNoOperations     = errors.New("no operations provided, use \"ops\" parameter with comma separated list")
+	errUnknownOperation = errors.New("unknown operation")
+)
+
+// INFO: operation is a single named computation on already parsed matrix. Result is formatted exactly as
+// response of the corresponding end-point. Computation stops when ctx is done.
+type operation func(ctx context.Context, matrix [][]string) (string, error)
+
+var operations = map[string]operation{
+	"echo": func(_ context.Context, matrix [][]string) (string, error) {
+		return convertToMatrixString(matrix), nil
+	},
+	"invert": func(ctx context.Context, matrix [][]string) (string, error) {
+		res, err := invertMatrix(ctx, matrix)
+		if err != nil {
+			return "", err
+		}
+		return convertToMatrixString(res), nil
+	},
+	"flatten": func(_ context.Context, matrix [][]string) (string, error) {
+		return convertToPlainString(matrix), nil
+	},
+	"sum": func(ctx context.Context, matrix [][]string) (string, error) {
+		res, err := sumMatrix(ctx, matrix)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d\n", res), nil
+	},
+	"multiply": func(ctx context.Context, matrix [][]string) (string, error) {
+		res, err := multiplyMatrix(ctx, matrix)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d\n", res), nil
+	},
+}
+
+type batchResult struct {
+	Operation string `json:"operation"`
+	Result    string `json:"result,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type batchResponse struct {
+	Results []batchResult `json:"results"`
+}
+
+// INFO: parses comma separated list of operations names, empty names are skipped.
+func parseOperations(raw string) ([]string, error) {
+	var ops []string
+	for _, op := range strings.Split(raw, ",") {
+		op = strings.TrimSpace(op)
+		if op != "" {
+			ops = append(ops, op)
+		}
+	}
+	if len(ops) == 0 {
+		return nil, errNoOperations
+	}
+	return ops, nil
+}
+
+// INFO: runs every operation on the same matrix. Failed operation is reported in its own result and
+// doesn't stop the others.
+func runBatch(ctx context.Context, matrix [][]string, ops []string) batchResponse {
+	resp := batchResponse{Results: make([]batchResult, 0, len(ops))}
+	for _, name := range ops {
+		res := batchResult{Operation: name}
+		op, ok := operations[name]
+		if !ok {
+			res.Error = fmt.Sprintf("%s: %q", errUnknownOperation.Error(), name)
+			resp.Results = append(resp.Results, res)
+			continue
+		}
+
+		out, err := op(ctx, matrix)
+		if err != nil {
+			res.Error = err.Error()
+		} else {
+			res.Result = out
+		}
+		resp.Results = append(resp.Results, res)
+	}
+	return resp
+}
+
+func (rout *Router) Batch(w http.ResponseWriter, r *http.Request) {
+	matrix, err := rout.extractData(r, fileKey)
+	if err != nil {
+		rout.log.Error("extracting data from .csv file failed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ops, err := parseOperations(r.FormValue(opsKey))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := runBatch(r.Context(), matrix, ops)
+	rout.log.Info("Batch command called", zap.Strings("operations", ops))
+
+	err = writeJSON(w, http.StatusOK, response)
+	if err != nil {
+		rout.log.Error("writing response failed", zap.Error(err))
+	}
+}
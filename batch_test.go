@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_parseOperations(t *testing.T) {
+	tt := []struct {
+		name           string
+		providedRaw    string
+		expectedResult []string
+		expectedErr    error
+	}{
+		{
+			name:           "fail: empty list",
+			providedRaw:    " , ",
+			expectedResult: nil,
+			expectedErr:    errNoOperations,
+		},
+		{
+			name:           "success: operations parsed",
+			providedRaw:    "sum, multiply,,flatten",
+			expectedResult: []string{"sum", "multiply", "flatten"},
+			expectedErr:    nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := parseOperations(tc.providedRaw)
+			assert.Equal(t, tc.expectedResult, res)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func Test_runBatch(t *testing.T) {
+	validMatrix := [][]string{{"1", "2"}, {"3", "4"}}
+	invalidMatrix := [][]string{{"1", "b"}, {"3", "4"}}
+
+	tt := []struct {
+		name           string
+		providedMatrix [][]string
+		providedOps    []string
+		expectedResult batchResponse
+	}{
+		{
+			name:           "fail: unknown operation doesn't hide the others",
+			providedMatrix: validMatrix,
+			providedOps:    []string{"unknown", "sum"},
+			expectedResult: batchResponse{Results: []batchResult{
+				{Operation: "unknown", Error: "unknown operation: \"unknown\""},
+				{Operation: "sum", Result: "10\n"},
+			}},
+		},
+		{
+			name:           "fail: numeric operations with non-int values",
+			providedMatrix: invalidMatrix,
+			providedOps:    []string{"sum", "flatten"},
+			expectedResult: batchResponse{Results: []batchResult{
+				{Operation: "sum", Error: errNotIntValue.Error()},
+				{Operation: "flatten", Result: "1,b,3,4\n"},
+			}},
+		},
+		{
+			name:           "success: all operations calculated",
+			providedMatrix: validMatrix,
+			providedOps:    []string{"echo", "invert", "flatten", "sum", "multiply"},
+			expectedResult: batchResponse{Results: []batchResult{
+				{Operation: "echo", Result: "1,2\n3,4\n"},
+				{Operation: "invert", Result: "1,3\n2,4\n"},
+				{Operation: "flatten", Result: "1,2,3,4\n"},
+				{Operation: "sum", Result: "10\n"},
+				{Operation: "multiply", Result: "24\n"},
+			}},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res := runBatch(context.Background(), tc.providedMatrix, tc.providedOps)
+			assert.Equal(t, tc.expectedResult, res)
+		})
+	}
+}
+
+func TestRouter_Batch(t *testing.T) {
+	validBody := `{"results":[{"operation":"sum","result":"45\n"},{"operation":"invert","result":"1,4,7\n2,5,8\n3,6,9\n"}]}` + "\n"
+	router, err := setupRouter()
+	assert.NoError(t, err)
+
+	successReq, successW, err := createReq(validPath, testURL+"?ops=sum,invert")
+	assert.NoError(t, err)
+	successReq.Header.Set("Content-Type", successW.FormDataContentType())
+
+	noOpsReq, noOpsW, err := createReq(validPath, testURL)
+	assert.NoError(t, err)
+	noOpsReq.Header.Set("Content-Type", noOpsW.FormDataContentType())
+
+	unsupportedReq, unsupportedW, err := createReq(unsupportedPath, testURL+"?ops=sum")
+	assert.NoError(t, err)
+	unsupportedReq.Header.Set("Content-Type", unsupportedW.FormDataContentType())
+
+	tt := []struct {
+		name         string
+		providedReq  *http.Request
+		expectedBody string
+		expectedCode int
+	}{
+		{
+			name:         "fail: extract data - BadRequest",
+			providedReq:  unsupportedReq,
+			expectedBody: errFileExtension.Error() + "\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "fail: no operations - BadRequest",
+			providedReq:  noOpsReq,
+			expectedBody: errNoOperations.Error() + "\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "success: batch calculated",
+			providedReq:  successReq,
+			expectedBody: validBody,
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.Batch(w, tc.providedReq)
+
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"hash"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	cacheStats = "/cache/stats"
+
+	cacheHeader = "X-Cache"
+	cacheHit    = "HIT"
+	cacheMiss   = "MISS"
+
+	defaultCacheMaxBytes = 64 << 20
+)
+
+var (
+	errNotCacheable = errors.New("request references stored matrices")
+)
+
+// INFO: response headers saved together with cached body, the others are set by middlewares on every request.
+var cachedHeaders = []string{"Content-Type", "Content-Disposition"}
+
+type cacheEntry struct {
+	key       string
+	etag      string
+	status    int
+	header    http.Header
+	body      []byte
+	expiresAt time.Time
+}
+
+// INFO: CacheStats are counters of result cache since server start.
+type CacheStats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Entries   int    `json:"entries"`
+	Bytes     int64  `json:"bytes"`
+}
+
+// INFO: resultCache keeps successful responses of operation end-points in LRU order, the least recently used
+// entries are evicted when number of entries or total size of bodies exceeds the limits.
+type resultCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int64
+	ttl        time.Duration
+	entries    map[string]*list.Element
+	lru        *list.List
+	stats      CacheStats
+	now        func() time.Time
+}
+
+func newResultCache(maxEntries int, maxBytes int64, ttl time.Duration) *resultCache {
+	return &resultCache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		ttl:        ttl,
+		entries:    map[string]*list.Element{},
+		lru:        list.New(),
+		now:        time.Now,
+	}
+}
+
+func (c *resultCache) get(key string) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if ok && !c.now().Before(elem.Value.(cacheEntry).expiresAt) {
+		c.remove(elem)
+		ok = false
+	}
+	if !ok {
+		c.stats.Misses++
+		return cacheEntry{}, false
+	}
+	c.stats.Hits++
+	c.lru.MoveToFront(elem)
+	return elem.Value.(cacheEntry), true
+}
+
+func (c *resultCache) put(entry cacheEntry) {
+	size := int64(len(entry.body))
+	if size > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[entry.key]; ok {
+		c.remove(elem)
+	}
+	entry.expiresAt = c.now().Add(c.ttl)
+	c.entries[entry.key] = c.lru.PushFront(entry)
+	c.stats.Entries++
+	c.stats.Bytes += size
+	for c.stats.Entries > c.maxEntries || c.stats.Bytes > c.maxBytes {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+func (c *resultCache) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(cacheEntry)
+	delete(c.entries, entry.key)
+	c.stats.Entries--
+	c.stats.Bytes -= int64(len(entry.body))
+}
+
+func (c *resultCache) snapshot() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// INFO: writes string with its length, so concatenation of different fields can't produce the same hash.
+func hashString(h hash.Hash, s string) {
+	_, _ = fmt.Fprintf(h, "%d:%s", len(s), s)
+}
+
+func sortedFormKeys[T any](values map[string]T) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// INFO: builds cache key from path, parameters and content of uploaded files. Multipart boundaries are random,
+// so the body is hashed after parsing. Requests with stored matrices aren't cached, otherwise deleted matrix
+// could still be used through the cache.
+func cacheKey(r *http.Request) (string, error) {
+	err := r.ParseMultipartForm(multipartMaxMemory)
+	if err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return "", err
+	}
+
+	h := sha256.New()
+	hashString(h, r.URL.Path)
+	for _, key := range sortedFormKeys(r.Form) {
+		if strings.HasSuffix(key, idKeySuffix) {
+			return "", errNotCacheable
+		}
+		hashString(h, key)
+		for _, v := range r.Form[key] {
+			hashString(h, v)
+		}
+	}
+	if r.MultipartForm == nil {
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+	for _, key := range sortedFormKeys(r.MultipartForm.File) {
+		hashString(h, key)
+		for _, header := range r.MultipartForm.File[key] {
+			hashString(h, header.Filename)
+			hashString(h, header.Header.Get("Content-Type"))
+			file, err := header.Open()
+			if err != nil {
+				return "", err
+			}
+			_, _ = fmt.Fprintf(h, "%d:", header.Size)
+			_, err = io.Copy(h, file)
+			_ = file.Close()
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func bodyETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// INFO: compares If-None-Match header with entity tag using weak comparison, so tags made weak by compression
+// still match.
+func etagMatches(header string, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *resultCache) write(w http.ResponseWriter, r *http.Request, entry cacheEntry, status string) {
+	w.Header().Set("ETag", entry.etag)
+	w.Header().Set(cacheHeader, status)
+	if etagMatches(r.Header.Get("If-None-Match"), entry.etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	for k, v := range entry.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(entry.status)
+	_, _ = w.Write(entry.body)
+}
+
+// INFO: serves repeated requests from the cache, only successful responses are cached.
+func (c *resultCache) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, err := cacheKey(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if entry, ok := c.get(key); ok {
+			c.write(w, r, entry, cacheHit)
+			return
+		}
+
+		resp := newBufferedResponse()
+		next.ServeHTTP(resp, r)
+		if resp.status == 0 {
+			resp.status = http.StatusOK
+		}
+		entry := cacheEntry{key: key, etag: bodyETag(resp.body.Bytes()), status: resp.status, header: http.Header{}, body: resp.body.Bytes()}
+		for _, name := range cachedHeaders {
+			if v := resp.header.Values(name); len(v) > 0 {
+				entry.header[name] = v
+			}
+		}
+		if resp.status != http.StatusOK {
+			for k, v := range resp.header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(resp.status)
+			_, _ = w.Write(entry.body)
+			return
+		}
+		c.put(entry)
+		c.write(w, r, entry, cacheMiss)
+	})
+}
+
+func (rout *Router) CacheStats(w http.ResponseWriter, _ *http.Request) {
+	var stats CacheStats
+	if rout.cache != nil {
+		stats = rout.cache.snapshot()
+	}
+
+	err := writeJSON(w, http.StatusOK, stats)
+	if err != nil {
+		rout.log.Error("writing response failed", zap.Error(err))
+	}
+}
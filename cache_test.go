@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_resultCache(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := newResultCache(2, 10, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.put(cacheEntry{key: "a", body: []byte("1234")})
+	c.put(cacheEntry{key: "b", body: []byte("1234")})
+	_, ok := c.get("a")
+	assert.True(t, ok)
+
+	// INFO: "b" is the least recently used one.
+	c.put(cacheEntry{key: "c", body: []byte("12")})
+	_, ok = c.get("b")
+	assert.False(t, ok)
+
+	// INFO: too large entry isn't cached at all, size limit evicts "a".
+	c.put(cacheEntry{key: "big", body: []byte("12345678901")})
+	c.put(cacheEntry{key: "d", body: []byte("123456")})
+	_, ok = c.get("a")
+	assert.False(t, ok)
+	_, ok = c.get("big")
+	assert.False(t, ok)
+
+	now = now.Add(time.Minute)
+	_, ok = c.get("d")
+	assert.False(t, ok)
+
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 4, Evictions: 2, Entries: 1, Bytes: 2}, c.snapshot())
+}
+
+func Test_etagMatches(t *testing.T) {
+	tt := []struct {
+		name           string
+		providedHeader string
+		expectedResult bool
+	}{
+		{
+			name:           "fail: no header",
+			providedHeader: "",
+			expectedResult: false,
+		},
+		{
+			name:           "fail: other tag",
+			providedHeader: `"other"`,
+			expectedResult: false,
+		},
+		{
+			name:           "success: one of tags",
+			providedHeader: `"other", "tag"`,
+			expectedResult: true,
+		},
+		{
+			name:           "success: weak tag",
+			providedHeader: `W/"tag"`,
+			expectedResult: true,
+		},
+		{
+			name:           "success: any tag",
+			providedHeader: "*",
+			expectedResult: true,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedResult, etagMatches(tc.providedHeader, `"tag"`))
+		})
+	}
+}
+
+func Test_cacheKey(t *testing.T) {
+	newReq := func(url string, path string) *http.Request {
+		req, err := createFilesReq(url, formFile{key: fileKey, path: path})
+		assert.NoError(t, err)
+		return req
+	}
+
+	key, err := cacheKey(newReq(testURL+sum, validPath))
+	assert.NoError(t, err)
+	sameKey, err := cacheKey(newReq(testURL+sum, validPath))
+	assert.NoError(t, err)
+	assert.Equal(t, key, sameKey)
+
+	for _, req := range []*http.Request{
+		newReq(testURL+multiply, validPath),
+		newReq(testURL+sum+"?axis=rows", validPath),
+		newReq(testURL+sum, notSquarePath),
+	} {
+		other, err := cacheKey(req)
+		assert.NoError(t, err)
+		assert.NotEqual(t, key, other)
+	}
+
+	_, err = cacheKey(newReq(testURL+solve+"?rhs_id=42", validPath))
+	assert.ErrorIs(t, err, errNotCacheable)
+}
+
+func TestRouter_cache(t *testing.T) {
+	logger, err := zap.NewProduction()
+	assert.NoError(t, err)
+	router, err := NewRouter(logger, WithResultCache(10, defaultCacheMaxBytes, time.Minute))
+	assert.NoError(t, err)
+	router.InitRoutes()
+	defer router.Close()
+
+	send := func(path string, header http.Header) *httptest.ResponseRecorder {
+		req, err := createFilesReq(testURL+sum, formFile{key: fileKey, path: path})
+		assert.NoError(t, err)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	miss := send(validPath, nil)
+	assert.Equal(t, http.StatusOK, miss.Result().StatusCode)
+	assert.Equal(t, cacheMiss, miss.Header().Get(cacheHeader))
+	etag := miss.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	tt := []struct {
+		name           string
+		providedPath   string
+		providedHeader http.Header
+		expectedCode   int
+		expectedCache  string
+		expectedETag   string
+		expectedBody   string
+	}{
+		{
+			name:          "fail: errors aren't cached - BadRequest",
+			providedPath:  notSquarePath,
+			expectedCode:  http.StatusBadRequest,
+			expectedBody:  errMatrixNotSquare.Error() + "\n",
+			expectedCache: "",
+		},
+		{
+			name:          "success: cached result",
+			providedPath:  validPath,
+			expectedCode:  http.StatusOK,
+			expectedCache: cacheHit,
+			expectedETag:  etag,
+			expectedBody:  "45\n",
+		},
+		{
+			name:           "success: not modified",
+			providedPath:   validPath,
+			providedHeader: http.Header{"If-None-Match": {etag}},
+			expectedCode:   http.StatusNotModified,
+			expectedCache:  cacheHit,
+			expectedETag:   etag,
+			expectedBody:   "",
+		},
+		{
+			name:           "success: compressed response has weak tag",
+			providedPath:   validPath,
+			providedHeader: http.Header{"Accept-Encoding": {encodingGzip}},
+			expectedCode:   http.StatusOK,
+			expectedCache:  cacheHit,
+			expectedETag:   "W/" + etag,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			w := send(tc.providedPath, tc.providedHeader)
+
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+			assert.Equal(t, tc.expectedCache, w.Header().Get(cacheHeader))
+			assert.Equal(t, tc.expectedETag, w.Header().Get("ETag"))
+			if tc.expectedBody != "" || tc.expectedCode == http.StatusNotModified {
+				assert.Equal(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+
+	statsW := httptest.NewRecorder()
+	router.ServeHTTP(statsW, httptest.NewRequest(http.MethodGet, testURL+cacheStats, nil))
+	var stats CacheStats
+	assert.NoError(t, json.NewDecoder(statsW.Body).Decode(&stats))
+	assert.Equal(t, CacheStats{Hits: 3, Misses: 2, Entries: 1, Bytes: 3}, stats)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"compress/gzip"
+	"errors"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	gzipExt = ".gz"
+	zstdExt = ".zst"
+
+	encodingGzip     = "gzip"
+	encodingIdentity = "identity"
+)
+
+var (
+	errExpandedTooLarge    = errors.New("decompressed data exceeds size limit")
+	errUnsupportedEncoding = errors.New("unsupported content encoding, only \"gzip\" is allowed")
+)
+
+// INFO: default upper bound of decompressed request body or file, protects from decompression bombs, see
+// WithMaxExpandedSize.
+const defaultMaxExpandedSize int64 = 64 << 20
+
+// INFO: limitedReader fails with errExpandedTooLarge instead of silent truncation like io.LimitReader.
+type limitedReader struct {
+	r    io.Reader
+	left int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > l.left+1 {
+		p = p[:l.left+1]
+	}
+	n, err := l.r.Read(p)
+	l.left -= int64(n)
+	if l.left < 0 {
+		return n, errExpandedTooLarge
+	}
+	return n, err
+}
+
+type gzipBody struct {
+	io.Reader
+	gz   *gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipBody) Close() error {
+	err := b.gz.Close()
+	if bodyErr := b.body.Close(); err == nil {
+		err = bodyErr
+	}
+	return err
+}
+
+// INFO: zstdReader reports limits of decoder memory and window as errExpandedTooLarge, the same as for gzip.
+type zstdReader struct {
+	dec *zstd.Decoder
+}
+
+func (z zstdReader) Read(p []byte) (int, error) {
+	n, err := z.dec.Read(p)
+	if errors.Is(err, zstd.ErrDecoderSizeExceeded) || errors.Is(err, zstd.ErrWindowSizeExceeded) {
+		return n, errExpandedTooLarge
+	}
+	return n, err
+}
+
+// INFO: unwraps compressed file by its extension. Returns reader of decompressed content and file name
+// without compression extension, so decoder can be found by the inner one (e.g. "matrix.csv.gz" -> "matrix.csv").
+// Decompressed content is limited to limit bytes.
+func decompressFile(file io.Reader, filename string, limit int64) (io.Reader, string, error) {
+	switch strings.ToLower(path.Ext(filename)) {
+	case gzipExt:
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, "", err
+		}
+		return &limitedReader{r: gz, left: limit}, strings.TrimSuffix(filename, path.Ext(filename)), nil
+	case zstdExt:
+		// INFO: single-threaded decoder works synchronously, so it doesn't hold goroutines and needs no Close.
+		// Window is bounded too, otherwise frame header alone could make decoder allocate a lot of memory.
+		zr, err := zstd.NewReader(file, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(uint64(limit)),
+			zstd.WithDecoderMaxWindow(uint64(limit)))
+		if err != nil {
+			return nil, "", err
+		}
+		return &limitedReader{r: zstdReader{zr}, left: limit}, strings.TrimSuffix(filename, path.Ext(filename)), nil
+	default:
+		return file, filename, nil
+	}
+}
+
+// INFO: decompresses request body sent with "Content-Encoding: gzip" before handlers parse multipart form.
+func (rout *Router) decompressRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))) {
+		case "", encodingIdentity:
+		case encodingGzip:
+			gz, err := gzip.NewReader(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			r.Body = &gzipBody{Reader: &limitedReader{r: gz, left: rout.maxExpandedSize}, gz: gz, body: r.Body}
+			r.Header.Del("Content-Encoding")
+			r.ContentLength = -1
+		default:
+			http.Error(w, errUnsupportedEncoding.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// INFO: checks that gzip is listed in Accept-Encoding header and isn't disabled with q=0.
+func acceptsGzip(header string) bool {
+	for _, item := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(item), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), encodingGzip) && strings.TrimSpace(coding) != "*" {
+			continue
+		}
+		_, q, ok := strings.Cut(strings.ReplaceAll(params, " ", ""), "q=")
+		if !ok {
+			return true
+		}
+		v, err := strconv.ParseFloat(q, 64)
+		return err == nil && v > 0
+	}
+	return false
+}
+
+// INFO: gzipResponseWriter compresses body written by handler. Responses without body (204, 304) and already
+// encoded ones are passed as is.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer
+	wroteHeader bool
+	passthrough bool
+}
+
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if status == http.StatusNoContent || status == http.StatusNotModified || w.Header().Get("Content-Encoding") != "" {
+		w.passthrough = true
+	} else {
+		w.Header().Del("Content-Length")
+		w.Header().Set("Content-Encoding", encodingGzip)
+		if etag := w.Header().Get("ETag"); strings.HasPrefix(etag, `"`) {
+			// INFO: compressed body differs from the one strong tag was calculated for.
+			w.Header().Set("ETag", "W/"+etag)
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.passthrough {
+		return w.ResponseWriter.Write(p)
+	}
+	return w.gz.Write(p)
+}
+
+// INFO: compresses response when client accepts gzip.
+func compressResponse(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w, gz: gzip.NewWriter(w)}
+		next.ServeHTTP(gw, r)
+		if gw.wroteHeader && !gw.passthrough {
+			_ = gw.gz.Close()
+		}
+	})
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+const (
+	gzipPath = "./data/matrix.csv.gz"
+)
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	_, err := gz.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func zstdBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	enc, err := zstd.NewWriter(nil)
+	assert.NoError(t, err)
+	defer enc.Close()
+	return enc.EncodeAll(data, nil)
+}
+
+func Test_limitedReader(t *testing.T) {
+	tt := []struct {
+		name           string
+		providedData   string
+		providedLimit  int64
+		expectedResult string
+		expectedErr    error
+	}{
+		{
+			name:           "fail: data exceeds limit",
+			providedData:   "1234567890",
+			providedLimit:  5,
+			expectedResult: "123456",
+			expectedErr:    errExpandedTooLarge,
+		},
+		{
+			name:           "success: data fits exactly",
+			providedData:   "12345",
+			providedLimit:  5,
+			expectedResult: "12345",
+			expectedErr:    nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := io.ReadAll(&limitedReader{r: strings.NewReader(tc.providedData), left: tc.providedLimit})
+			assert.Equal(t, tc.expectedResult, string(res))
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func Test_decompressFile(t *testing.T) {
+	compressed, err := os.ReadFile(gzipPath)
+	assert.NoError(t, err)
+
+	tt := []struct {
+		name             string
+		providedData     []byte
+		providedFilename string
+		expectedContent  string
+		expectedFilename string
+		expectedErr      error
+	}{
+		{
+			name:             "success: plain file",
+			providedData:     []byte("1,2\n3,4\n"),
+			providedFilename: "matrix.csv",
+			expectedContent:  "1,2\n3,4\n",
+			expectedFilename: "matrix.csv",
+		},
+		{
+			name:             "success: gzip file",
+			providedData:     compressed,
+			providedFilename: "matrix.CSV.GZ",
+			expectedContent:  "1,2,3\n4,5,6\n7,8,9",
+			expectedFilename: "matrix.CSV",
+		},
+		{
+			name:             "success: zstd file",
+			providedData:     zstdBytes(t, []byte("1,2\n3,4\n")),
+			providedFilename: "matrix.csv.zst",
+			expectedContent:  "1,2\n3,4\n",
+			expectedFilename: "matrix.csv",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			content, filename, err := decompressFile(bytes.NewReader(tc.providedData), tc.providedFilename, defaultMaxExpandedSize)
+			assert.ErrorIs(t, err, tc.expectedErr)
+			assert.Equal(t, tc.expectedFilename, filename)
+			if err != nil {
+				return
+			}
+			res, err := io.ReadAll(content)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedContent, string(res))
+		})
+	}
+}
+
+func Test_acceptsGzip(t *testing.T) {
+	tt := []struct {
+		name           string
+		providedHeader string
+		expectedResult bool
+	}{
+		{
+			name:           "fail: no header",
+			providedHeader: "",
+			expectedResult: false,
+		},
+		{
+			name:           "fail: gzip disabled",
+			providedHeader: "br, gzip;q=0",
+			expectedResult: false,
+		},
+		{
+			name:           "success: gzip listed",
+			providedHeader: "deflate, GZIP",
+			expectedResult: true,
+		},
+		{
+			name:           "success: gzip with weight",
+			providedHeader: "br;q=1.0, gzip; q=0.5",
+			expectedResult: true,
+		},
+		{
+			name:           "success: wildcard",
+			providedHeader: "*",
+			expectedResult: true,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedResult, acceptsGzip(tc.providedHeader))
+		})
+	}
+}
+
+func TestRouter_compression(t *testing.T) {
+	router, err := setupRouter()
+	assert.NoError(t, err)
+
+	gzipFileReq, err := createFilesReq(testURL+echo, formFile{key: fileKey, path: gzipPath})
+	assert.NoError(t, err)
+	gzipFileReq.Header.Set("Accept-Encoding", "gzip")
+
+	body := new(bytes.Buffer)
+	gzipBodyReq, err := createFilesReq(testURL+sum, formFile{key: fileKey, path: validPath})
+	assert.NoError(t, err)
+	_, err = body.ReadFrom(gzipBodyReq.Body)
+	assert.NoError(t, err)
+	gzipBodyReq.Body = io.NopCloser(bytes.NewReader(gzipBytes(t, body.Bytes())))
+	gzipBodyReq.Header.Set("Content-Encoding", "gzip")
+
+	brotliReq, err := createFilesReq(testURL+sum, formFile{key: fileKey, path: validPath})
+	assert.NoError(t, err)
+	brotliReq.Header.Set("Content-Encoding", "br")
+
+	tt := []struct {
+		name             string
+		providedReq      *http.Request
+		expectedBody     string
+		expectedCode     int
+		expectedEncoding string
+	}{
+		{
+			name:         "fail: unsupported request encoding - UnsupportedMediaType",
+			providedReq:  brotliReq,
+			expectedBody: errUnsupportedEncoding.Error() + "\n",
+			expectedCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:         "success: gzip request body",
+			providedReq:  gzipBodyReq,
+			expectedBody: "45\n",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:             "success: gzip file with gzip response",
+			providedReq:      gzipFileReq,
+			expectedBody:     "1,2,3\n4,5,6\n7,8,9\n",
+			expectedCode:     http.StatusOK,
+			expectedEncoding: encodingGzip,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, tc.providedReq)
+
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+			assert.Equal(t, tc.expectedEncoding, w.Header().Get("Content-Encoding"))
+			var res io.Reader = w.Body
+			if tc.expectedEncoding == encodingGzip {
+				res, err = gzip.NewReader(w.Body)
+				assert.NoError(t, err)
+			}
+			content, err := io.ReadAll(res)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedBody, string(content))
+		})
+	}
+}
+
+func TestRouter_decompressionBomb(t *testing.T) {
+	router, err := setupRouter(WithMaxExpandedSize(1 << 10))
+	assert.NoError(t, err)
+
+	data := bytes.Repeat([]byte("0,"), 1<<20)
+	tt := []struct {
+		name             string
+		providedFilename string
+		providedBomb     []byte
+	}{
+		{
+			name:             "fail: gzip bomb - BadRequest",
+			providedFilename: "bomb.csv.gz",
+			providedBomb:     gzipBytes(t, data),
+		},
+		{
+			name:             "fail: zstd bomb - BadRequest",
+			providedFilename: "bomb.csv.zst",
+			providedBomb:     zstdBytes(t, data),
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			body := new(bytes.Buffer)
+			writer := multipartWriterWithFile(t, body, tc.providedFilename, tc.providedBomb)
+			req := httptest.NewRequest(http.MethodPost, testURL+sum, body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+			assert.Equal(t, errExpandedTooLarge.Error()+"\n", w.Body.String())
+		})
+	}
+}
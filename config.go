@@ -0,0 +1,251 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// INFO: directory for uploaded matrices, they are kept in memory when it isn't set.
+	matrixStoreDirEnv = "MATRIX_STORE_DIR"
+	// INFO: result cache is enabled when max number of entries is set, e.g. RESULT_CACHE_ENTRIES=1000
+	// RESULT_CACHE_TTL=5m.
+	resultCacheEntriesEnv = "RESULT_CACHE_ENTRIES"
+	resultCacheTTLEnv     = "RESULT_CACHE_TTL"
+	// INFO: number of goroutines for computations on large matrices, GOMAXPROCS by default.
+	workersEnv = "MATRIX_WORKERS"
+	// INFO: global limit of concurrent computations is enabled when MAX_CONCURRENT is set.
+	maxConcurrentEnv = "MAX_CONCURRENT"
+	maxQueuedEnv     = "MAX_QUEUED"
+	queueTimeoutEnv  = "QUEUE_TIMEOUT"
+	// INFO: per-client rate limit of all routes, e.g. RATE_LIMIT=10 RATE_LIMIT_BURST=20, and of separate
+	// routes, e.g. ROUTE_RATE_LIMITS="/decompose=1:2,/solve=2:5" where values are rate and burst.
+	rateLimitEnv       = "RATE_LIMIT"
+	rateLimitBurstEnv  = "RATE_LIMIT_BURST"
+	routeRateLimitsEnv = "ROUTE_RATE_LIMITS"
+	// INFO: authentication is enabled when any of credentials sources is set. API keys file is JSON list of
+	// {"key", "name", "permissions"} objects, JWT secret is used for HS256 tokens and JWKS file for RS256 ones.
+	apiKeysFileEnv = "API_KEYS_FILE"
+	jwtSecretEnv   = "JWT_SECRET"
+	jwksFileEnv    = "JWKS_FILE"
+	// INFO: CORS is enabled when allowed origins are set, e.g. CORS_ALLOWED_ORIGINS=https://ui.example.com.
+	// Other values are comma separated lists too, defaults are used for missing ones.
+	corsOriginsEnv     = "CORS_ALLOWED_ORIGINS"
+	corsMethodsEnv     = "CORS_ALLOWED_METHODS"
+	corsHeadersEnv     = "CORS_ALLOWED_HEADERS"
+	corsExposedEnv     = "CORS_EXPOSED_HEADERS"
+	corsCredentialsEnv = "CORS_ALLOW_CREDENTIALS"
+	corsMaxAgeEnv      = "CORS_MAX_AGE"
+
+	defaultResultCacheTTL = 5 * time.Minute
+	defaultQueueTimeout   = 10 * time.Second
+	defaultCORSMaxAge     = 10 * time.Minute
+)
+
+var (
+	errInvalidConfig = errors.New("invalid configuration")
+)
+
+// INFO: env reads configuration values by name, e.g. os.Getenv.
+type env func(key string) string
+
+func (e env) positiveInt(key string, fallback int) (int, error) {
+	raw := e(key)
+	if raw == "" {
+		return fallback, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("%w: %s should be positive integer, got %q", errInvalidConfig, key, raw)
+	}
+	return v, nil
+}
+
+func (e env) positiveFloat(key string, fallback float64) (float64, error) {
+	raw := e(key)
+	if raw == "" {
+		return fallback, nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || !(v > 0) {
+		return 0, fmt.Errorf("%w: %s should be positive number, got %q", errInvalidConfig, key, raw)
+	}
+	return v, nil
+}
+
+func (e env) duration(key string, fallback time.Duration) (time.Duration, error) {
+	raw := e(key)
+	if raw == "" {
+		return fallback, nil
+	}
+	v, err := time.ParseDuration(raw)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("%w: %s should be positive duration, got %q", errInvalidConfig, key, raw)
+	}
+	return v, nil
+}
+
+func (e env) list(key string) []string {
+	var items []string
+	for _, item := range strings.Split(e(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (e env) boolean(key string) (bool, error) {
+	raw := e(key)
+	if raw == "" {
+		return false, nil
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s should be boolean, got %q", errInvalidConfig, key, raw)
+	}
+	return v, nil
+}
+
+// INFO: default burst lets client send one second worth of requests at once.
+func defaultBurst(rate float64) int {
+	return int(rate + 0.999999)
+}
+
+// INFO: parses "pattern=rate:burst" list, burst may be omitted.
+func parseRouteRateLimits(raw string) ([]Option, error) {
+	var opts []Option
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		pattern, limit, ok := strings.Cut(item, "=")
+		rawRate, rawBurst, hasBurst := strings.Cut(limit, ":")
+		rate, err := strconv.ParseFloat(rawRate, 64)
+		if !ok || !strings.HasPrefix(pattern, "/") || err != nil || !(rate > 0) {
+			return nil, fmt.Errorf("%w: %s item should be \"pattern=rate[:burst]\", got %q", errInvalidConfig, routeRateLimitsEnv, item)
+		}
+		burst := defaultBurst(rate)
+		if hasBurst {
+			burst, err = strconv.Atoi(rawBurst)
+			if err != nil || burst <= 0 {
+				return nil, fmt.Errorf("%w: %s burst should be positive integer, got %q", errInvalidConfig, routeRateLimitsEnv, item)
+			}
+		}
+		opts = append(opts, WithRateLimit(rate, burst, pattern))
+	}
+	return opts, nil
+}
+
+// INFO: builds router options from environment, everything is optional.
+func optionsFromEnv(e env) ([]Option, error) {
+	var opts []Option
+	if dir := e(matrixStoreDirEnv); dir != "" {
+		store, err := NewDiskMatrixStore(dir, MatrixQuota{MaxBytes: defaultMatrixMaxBytes, MaxMatrices: defaultMatrixMaxMatrices})
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithMatrixStore(store))
+	}
+
+	if e(resultCacheEntriesEnv) != "" {
+		entries, err := e.positiveInt(resultCacheEntriesEnv, 0)
+		if err != nil {
+			return nil, err
+		}
+		ttl, err := e.duration(resultCacheTTLEnv, defaultResultCacheTTL)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithResultCache(entries, defaultCacheMaxBytes, ttl))
+	}
+
+	workers, err := e.positiveInt(workersEnv, 0)
+	if err != nil {
+		return nil, err
+	}
+	if workers > 0 {
+		opts = append(opts, WithWorkers(workers))
+	}
+
+	if e(maxConcurrentEnv) != "" {
+		maxActive, err := e.positiveInt(maxConcurrentEnv, 0)
+		if err != nil {
+			return nil, err
+		}
+		maxQueued, err := e.positiveInt(maxQueuedEnv, 2*maxActive)
+		if err != nil {
+			return nil, err
+		}
+		timeout, err := e.duration(queueTimeoutEnv, defaultQueueTimeout)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithConcurrencyLimit(maxActive, maxQueued, timeout))
+	}
+
+	if e(rateLimitEnv) != "" {
+		rate, err := e.positiveFloat(rateLimitEnv, 0)
+		if err != nil {
+			return nil, err
+		}
+		burst, err := e.positiveInt(rateLimitBurstEnv, defaultBurst(rate))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithRateLimit(rate, burst))
+	}
+	routeLimits, err := parseRouteRateLimits(e(routeRateLimitsEnv))
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts, routeLimits...)
+
+	if path := e(apiKeysFileEnv); path != "" {
+		keys, err := LoadAPIKeys(path)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithAPIKeys(keys...))
+	}
+	if secret := e(jwtSecretEnv); secret != "" {
+		opts = append(opts, WithJWTSecret([]byte(secret)))
+	}
+	if path := e(jwksFileEnv); path != "" {
+		keys, err := LoadJWKS(path)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithJWKS(keys))
+	}
+
+	if origins := e.list(corsOriginsEnv); len(origins) > 0 {
+		credentials, err := e.boolean(corsCredentialsEnv)
+		if err != nil {
+			return nil, err
+		}
+		maxAge, err := e.duration(corsMaxAgeEnv, defaultCORSMaxAge)
+		if err != nil {
+			return nil, err
+		}
+		cfg := CORSConfig{
+			AllowedOrigins:   origins,
+			AllowedMethods:   e.list(corsMethodsEnv),
+			AllowedHeaders:   e.list(corsHeadersEnv),
+			ExposedHeaders:   e.list(corsExposedEnv),
+			AllowCredentials: credentials,
+			MaxAge:           maxAge,
+		}
+		if err := cfg.validate(); err != nil {
+			return nil, fmt.Errorf("%w: %s: %s", errInvalidConfig, corsCredentialsEnv, err.Error())
+		}
+		opts = append(opts, WithCORS(cfg))
+	}
+
+	return opts, nil
+}
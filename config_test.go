@@ -0,0 +1,85 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_optionsFromEnv(t *testing.T) {
+	tt := []struct {
+		name          string
+		providedEnv   map[string]string
+		expectedCount int
+		expectedErr   error
+	}{
+		{
+			name:        "fail: not a number",
+			providedEnv: map[string]string{workersEnv: "many"},
+			expectedErr: errInvalidConfig,
+		},
+		{
+			name:        "fail: zero cache size",
+			providedEnv: map[string]string{resultCacheEntriesEnv: "0"},
+			expectedErr: errInvalidConfig,
+		},
+		{
+			name:        "fail: invalid queue timeout",
+			providedEnv: map[string]string{maxConcurrentEnv: "2", queueTimeoutEnv: "10"},
+			expectedErr: errInvalidConfig,
+		},
+		{
+			name:        "fail: negative rate",
+			providedEnv: map[string]string{rateLimitEnv: "-1"},
+			expectedErr: errInvalidConfig,
+		},
+		{
+			name:        "fail: route limit without pattern",
+			providedEnv: map[string]string{routeRateLimitsEnv: "decompose=1"},
+			expectedErr: errInvalidConfig,
+		},
+		{
+			name:        "fail: route limit with invalid burst",
+			providedEnv: map[string]string{routeRateLimitsEnv: "/decompose=1:0"},
+			expectedErr: errInvalidConfig,
+		},
+		{
+			name:        "fail: credentials with any origin",
+			providedEnv: map[string]string{corsOriginsEnv: "*", corsCredentialsEnv: "true"},
+			expectedErr: errInvalidConfig,
+		},
+		{
+			name:        "fail: invalid credentials flag",
+			providedEnv: map[string]string{corsOriginsEnv: "https://ui.example.com", corsCredentialsEnv: "yes please"},
+			expectedErr: errInvalidConfig,
+		},
+		{
+			name:          "success: nothing configured",
+			providedEnv:   map[string]string{},
+			expectedCount: 0,
+		},
+		{
+			name: "success: all options",
+			providedEnv: map[string]string{
+				resultCacheEntriesEnv: "10",
+				resultCacheTTLEnv:     "1m",
+				maxConcurrentEnv:      "2",
+				rateLimitEnv:          "0.5",
+				routeRateLimitsEnv:    "/decompose=1:2, /solve=3",
+				corsOriginsEnv:        "https://ui.example.com, https://admin.example.com",
+				corsCredentialsEnv:    "true",
+			},
+			expectedCount: 6,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := optionsFromEnv(func(key string) string {
+				return tc.providedEnv[key]
+			})
+			assert.Len(t, res, tc.expectedCount)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	corsAnyOrigin = "*"
+
+	originHeader           = "Origin"
+	requestMethodHeader    = "Access-Control-Request-Method"
+	requestHeadersHeader   = "Access-Control-Request-Headers"
+	allowOriginHeader      = "Access-Control-Allow-Origin"
+	allowMethodsHeader     = "Access-Control-Allow-Methods"
+	allowHeadersHeader     = "Access-Control-Allow-Headers"
+	allowCredentialsHeader = "Access-Control-Allow-Credentials"
+	exposeHeadersHeader    = "Access-Control-Expose-Headers"
+	maxAgeHeader           = "Access-Control-Max-Age"
+)
+
+var errCORSCredentials = errors.New("credentials can't be allowed for any origin \"*\"")
+
+var (
+	// INFO: multipart uploads themselves don't need preflight, but credentials, compressed bodies and
+	// conditional requests do.
+	defaultCORSMethods = []string{http.MethodGet, http.MethodPost, http.MethodDelete}
+	defaultCORSHeaders = []string{"Authorization", "Content-Type", "Content-Encoding", "If-None-Match", apiKeyHeader}
+	// INFO: headers set by handlers and middlewares which browser clients may need to read.
+	defaultCORSExposed = []string{"ETag", "Location", "Retry-After", "Content-Disposition", "WWW-Authenticate", cacheHeader}
+)
+
+// INFO: CORSConfig describes which cross-origin browser requests are allowed. Origins are compared exactly,
+// "*" allows any origin. Empty methods, headers and exposed headers are replaced with defaults.
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+type cors struct {
+	anyOrigin   bool
+	origins     map[string]bool
+	methods     map[string]bool
+	headers     map[string]bool
+	anyHeader   bool
+	allowMethod string
+	allowHeader string
+	expose      string
+	credentials bool
+	maxAge      string
+}
+
+// INFO: browsers refuse credentialed responses with any origin, so such configuration is rejected instead of
+// silently breaking clients.
+func (cfg CORSConfig) validate() error {
+	if !cfg.AllowCredentials {
+		return nil
+	}
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == corsAnyOrigin {
+			return errCORSCredentials
+		}
+	}
+	return nil
+}
+
+func newCORS(cfg CORSConfig) (*cors, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	if len(cfg.AllowedMethods) == 0 {
+		cfg.AllowedMethods = defaultCORSMethods
+	}
+	if len(cfg.AllowedHeaders) == 0 {
+		cfg.AllowedHeaders = defaultCORSHeaders
+	}
+	if len(cfg.ExposedHeaders) == 0 {
+		cfg.ExposedHeaders = defaultCORSExposed
+	}
+
+	c := &cors{
+		origins:     map[string]bool{},
+		methods:     map[string]bool{},
+		headers:     map[string]bool{},
+		credentials: cfg.AllowCredentials,
+		expose:      strings.Join(cfg.ExposedHeaders, ", "),
+	}
+	for _, origin := range cfg.AllowedOrigins {
+		c.anyOrigin = c.anyOrigin || origin == corsAnyOrigin
+		c.origins[origin] = true
+	}
+	var methods, headers []string
+	for _, method := range cfg.AllowedMethods {
+		method = strings.ToUpper(method)
+		c.methods[method] = true
+		methods = append(methods, method)
+	}
+	for _, header := range cfg.AllowedHeaders {
+		c.anyHeader = c.anyHeader || header == "*"
+		c.headers[http.CanonicalHeaderKey(header)] = true
+		headers = append(headers, http.CanonicalHeaderKey(header))
+	}
+	c.allowMethod = strings.Join(methods, ", ")
+	c.allowHeader = strings.Join(headers, ", ")
+	if cfg.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	}
+	return c, nil
+}
+
+func (c *cors) originAllowed(origin string) bool {
+	return c.anyOrigin || c.origins[origin]
+}
+
+// INFO: requested headers are listed in one or several comma separated values.
+func (c *cors) headersAllowed(requested []string) bool {
+	if c.anyHeader {
+		return true
+	}
+	for _, value := range requested {
+		for _, header := range strings.Split(value, ",") {
+			header = strings.TrimSpace(header)
+			if header != "" && !c.headers[http.CanonicalHeaderKey(header)] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// INFO: allowed origin is echoed back, "*" is sent only for any origin without credentials since browsers
+// reject it for credentialed requests.
+func (c *cors) setOrigin(h http.Header, origin string) {
+	if c.anyOrigin && !c.credentials {
+		h.Set(allowOriginHeader, corsAnyOrigin)
+	} else {
+		h.Set(allowOriginHeader, origin)
+	}
+	if c.credentials {
+		h.Set(allowCredentialsHeader, "true")
+	}
+}
+
+// INFO: answers preflight requests without calling the handler, so they don't need credentials and aren't
+// counted by limits. Other requests from allowed origins get CORS headers, the handler decides the rest.
+// Responses of not allowed origins have no CORS headers, so browser blocks them.
+func (c *cors) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get(originHeader)
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		h := w.Header()
+		h.Add("Vary", originHeader)
+
+		if r.Method == http.MethodOptions && r.Header.Get(requestMethodHeader) != "" {
+			h.Add("Vary", requestMethodHeader)
+			h.Add("Vary", requestHeadersHeader)
+			method := strings.ToUpper(r.Header.Get(requestMethodHeader))
+			if c.originAllowed(origin) && c.methods[method] && c.headersAllowed(r.Header.Values(requestHeadersHeader)) {
+				c.setOrigin(h, origin)
+				h.Set(allowMethodsHeader, c.allowMethod)
+				if c.anyHeader {
+					h.Set(allowHeadersHeader, strings.Join(r.Header.Values(requestHeadersHeader), ", "))
+				} else {
+					h.Set(allowHeadersHeader, c.allowHeader)
+				}
+				if c.maxAge != "" {
+					h.Set(maxAgeHeader, c.maxAge)
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if c.originAllowed(origin) {
+			c.setOrigin(h, origin)
+			h.Set(exposeHeadersHeader, c.expose)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const uiOrigin = "https://ui.example.com"
+
+func TestRouter_cors(t *testing.T) {
+	logger, err := zap.NewProduction()
+	assert.NoError(t, err)
+	keys, err := LoadAPIKeys(keysPath)
+	assert.NoError(t, err)
+
+	tt := []struct {
+		name            string
+		providedCORS    CORSConfig
+		providedMethod  string
+		providedOrigin  string
+		providedHeaders map[string]string
+		expectedCode    int
+		expectedHeaders map[string]string
+	}{
+		{
+			name:           "fail: preflight of not allowed origin",
+			providedCORS:   CORSConfig{AllowedOrigins: []string{uiOrigin}},
+			providedMethod: http.MethodOptions,
+			providedOrigin: "https://evil.example.com",
+			providedHeaders: map[string]string{
+				requestMethodHeader: http.MethodPost,
+			},
+			expectedCode:    http.StatusNoContent,
+			expectedHeaders: map[string]string{allowOriginHeader: "", allowMethodsHeader: ""},
+		},
+		{
+			name:           "fail: preflight of not allowed method",
+			providedCORS:   CORSConfig{AllowedOrigins: []string{uiOrigin}},
+			providedMethod: http.MethodOptions,
+			providedOrigin: uiOrigin,
+			providedHeaders: map[string]string{
+				requestMethodHeader: http.MethodPut,
+			},
+			expectedCode:    http.StatusNoContent,
+			expectedHeaders: map[string]string{allowOriginHeader: ""},
+		},
+		{
+			name:           "fail: preflight of not allowed header",
+			providedCORS:   CORSConfig{AllowedOrigins: []string{uiOrigin}},
+			providedMethod: http.MethodOptions,
+			providedOrigin: uiOrigin,
+			providedHeaders: map[string]string{
+				requestMethodHeader:  http.MethodPost,
+				requestHeadersHeader: "x-api-key, x-debug",
+			},
+			expectedCode:    http.StatusNoContent,
+			expectedHeaders: map[string]string{allowOriginHeader: ""},
+		},
+		{
+			name:           "fail: not allowed origin gets no CORS headers",
+			providedCORS:   CORSConfig{AllowedOrigins: []string{uiOrigin}},
+			providedMethod: http.MethodPost,
+			providedOrigin: "https://evil.example.com",
+			providedHeaders: map[string]string{
+				apiKeyHeader: "dashboard-key",
+			},
+			expectedCode:    http.StatusOK,
+			expectedHeaders: map[string]string{allowOriginHeader: "", exposeHeadersHeader: ""},
+		},
+		{
+			name:           "fail: credentials error is readable by browser",
+			providedCORS:   CORSConfig{AllowedOrigins: []string{uiOrigin}},
+			providedMethod: http.MethodPost,
+			providedOrigin: uiOrigin,
+			expectedCode:   http.StatusUnauthorized,
+			expectedHeaders: map[string]string{
+				allowOriginHeader: uiOrigin,
+				"Vary":            originHeader,
+			},
+		},
+		{
+			name:           "success: preflight of multipart upload with credentials",
+			providedCORS:   CORSConfig{AllowedOrigins: []string{uiOrigin}, AllowCredentials: true, MaxAge: time.Hour},
+			providedMethod: http.MethodOptions,
+			providedOrigin: uiOrigin,
+			providedHeaders: map[string]string{
+				requestMethodHeader:  http.MethodPost,
+				requestHeadersHeader: "content-type,x-api-key",
+			},
+			expectedCode: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				allowOriginHeader:      uiOrigin,
+				allowCredentialsHeader: "true",
+				allowMethodsHeader:     "GET, POST, DELETE",
+				allowHeadersHeader:     "Authorization, Content-Type, Content-Encoding, If-None-Match, X-Api-Key",
+				maxAgeHeader:           "3600",
+			},
+		},
+		{
+			name:           "success: preflight with any header",
+			providedCORS:   CORSConfig{AllowedOrigins: []string{"*"}, AllowedHeaders: []string{"*"}},
+			providedMethod: http.MethodOptions,
+			providedOrigin: uiOrigin,
+			providedHeaders: map[string]string{
+				requestMethodHeader:  http.MethodPost,
+				requestHeadersHeader: "x-debug",
+			},
+			expectedCode: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				allowOriginHeader:  "*",
+				allowHeadersHeader: "x-debug",
+				maxAgeHeader:       "",
+			},
+		},
+		{
+			name:           "success: request from allowed origin",
+			providedCORS:   CORSConfig{AllowedOrigins: []string{uiOrigin}, ExposedHeaders: []string{"ETag"}},
+			providedMethod: http.MethodPost,
+			providedOrigin: uiOrigin,
+			providedHeaders: map[string]string{
+				apiKeyHeader: "dashboard-key",
+			},
+			expectedCode: http.StatusOK,
+			expectedHeaders: map[string]string{
+				allowOriginHeader:      uiOrigin,
+				allowCredentialsHeader: "",
+				exposeHeadersHeader:    "ETag",
+			},
+		},
+		{
+			name:           "success: request without origin",
+			providedCORS:   CORSConfig{AllowedOrigins: []string{"*"}},
+			providedMethod: http.MethodPost,
+			providedHeaders: map[string]string{
+				apiKeyHeader: "dashboard-key",
+			},
+			expectedCode:    http.StatusOK,
+			expectedHeaders: map[string]string{allowOriginHeader: "", "Vary": "Accept-Encoding"},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			router, err := NewRouter(logger, WithAPIKeys(keys...), WithCORS(tc.providedCORS))
+			assert.NoError(t, err)
+			router.InitRoutes()
+			defer router.Close()
+
+			req, err := createFilesReq(testURL+sum, formFile{key: fileKey, path: validPath})
+			assert.NoError(t, err)
+			req.Method = tc.providedMethod
+			if tc.providedOrigin != "" {
+				req.Header.Set(originHeader, tc.providedOrigin)
+			}
+			for k, v := range tc.providedHeaders {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+			for k, v := range tc.expectedHeaders {
+				assert.Equal(t, v, w.Result().Header.Get(k), k)
+			}
+		})
+	}
+}
+
+func Test_newCORS(t *testing.T) {
+	tt := []struct {
+		name         string
+		providedCORS CORSConfig
+		expectedErr  error
+	}{
+		{
+			name:         "fail: credentials with any origin",
+			providedCORS: CORSConfig{AllowedOrigins: []string{uiOrigin, corsAnyOrigin}, AllowCredentials: true},
+			expectedErr:  errCORSCredentials,
+		},
+		{
+			name:         "success: any origin without credentials",
+			providedCORS: CORSConfig{AllowedOrigins: []string{corsAnyOrigin}},
+		},
+		{
+			name:         "success: credentials with listed origin",
+			providedCORS: CORSConfig{AllowedOrigins: []string{uiOrigin}, AllowCredentials: true},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newCORS(tc.providedCORS)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+
+	router, err := NewRouter(zap.NewNop(), WithCORS(CORSConfig{AllowedOrigins: []string{corsAnyOrigin}, AllowCredentials: true}))
+	assert.Nil(t, router)
+	assert.ErrorIs(t, err, errCORSCredentials)
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	sheetKey = "sheet"
+
+	tsvExt  = ".tsv"
+	txtExt  = ".txt"
+	xlsxExt = ".xlsx"
+
+	xlsxWorkbook      = "xl/workbook.xml"
+	xlsxWorkbookRels  = "xl/_rels/workbook.xml.rels"
+	xlsxSharedStrings = "xl/sharedStrings.xml"
+
+	// INFO: worksheet bounds of Excel, the last cell is XFD1048576.
+	xlsxMaxRows       = 1 << 20
+	xlsxMaxCols       = 1 << 14
+	xlsxMaxColLetters = 3
+)
+
+var (
+	errUnknownSheet = errors.New("sheet not found in workbook")
+	errInvalidXLSX  = errors.New("invalid xlsx workbook")
+	errTooManyCells = fmt.Errorf("used range of sheet should not exceed %d cells", maxDenseCells)
+)
+
+// INFO: decodeOptions are optional format specific settings taken from the request.
+type decodeOptions struct {
+	sheet string
+	// INFO: upper bound of decompressed parts of archive formats, see WithMaxExpandedSize.
+	maxExpandedSize int64
+}
+
+// INFO: decoder reads matrix from uploaded file content. Result may be nil for empty file, squareness and
+// emptiness are checked by the caller.
+type decoder func(r io.Reader, opts decodeOptions) ([][]string, error)
+
+var (
+	decodersByExt         = map[string]decoder{}
+	decodersByContentType = map[string]decoder{}
+)
+
+// INFO: registers decoder for file extensions (with leading dot) and content types of the uploaded part.
+// Extensions are matched case-insensitively.
+func registerDecoder(d decoder, extensions []string, contentTypes []string) {
+	for _, ext := range extensions {
+		decodersByExt[strings.ToLower(ext)] = d
+	}
+	for _, ct := range contentTypes {
+		decodersByContentType[ct] = d
+	}
+}
+
+func init() {
+	registerDecoder(decodeCSV, []string{csvExt}, []string{"text/csv", "application/csv"})
+	registerDecoder(decodeTSV, []string{tsvExt}, []string{"text/tab-separated-values"})
+	registerDecoder(decodeText, []string{txtExt}, []string{"text/plain"})
+	registerDecoder(decodeMatrixMarket, []string{mtxExt}, []string{"application/x-matrix-market", "text/x-matrix-market"})
+	registerDecoder(decodeXLSX, []string{xlsxExt},
+		[]string{"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"})
+}
+
+// INFO: finds decoder by file extension first, content type of the part is used for unknown extensions only,
+// because browsers often send generic types like application/octet-stream.
+func lookupDecoder(filename string, contentType string) (decoder, error) {
+	if d, ok := decodersByExt[strings.ToLower(path.Ext(filename))]; ok {
+		return d, nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil {
+		if d, ok := decodersByContentType[mediaType]; ok {
+			return d, nil
+		}
+	}
+	return nil, errFileExtension
+}
+
+func decodeCSV(r io.Reader, _ decodeOptions) ([][]string, error) {
+	return csv.NewReader(r).ReadAll()
+}
+
+func decodeTSV(r io.Reader, _ decodeOptions) ([][]string, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = '\t'
+	return reader.ReadAll()
+}
+
+// INFO: plain text where values are separated by whitespaces and/or commas, blank lines are skipped.
+func decodeText(r io.Reader, _ decodeOptions) ([][]string, error) {
+	var res [][]string
+	err := readLines(r, func(line []byte) error {
+		fields := strings.FieldsFunc(string(line), func(c rune) bool {
+			return c == ',' || unicode.IsSpace(c)
+		})
+		if len(fields) == 0 {
+			return nil
+		}
+		if len(res) > 0 && len(fields) != len(res[0]) {
+			return fmt.Errorf("line %d: wrong number of fields", len(res)+1)
+		}
+		res = append(res, fields)
+		return nil
+	})
+	return res, err
+}
+
+// INFO: calls fn for every line without line break. Unlike bufio.Scanner lines aren't limited in length, wide
+// rows are limited only by the size of the upload.
+func readLines(r io.Reader, fn func(line []byte) error) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			if fnErr := fn(bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r"))); fnErr != nil {
+				return fnErr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func decodeMatrixMarket(r io.Reader, _ decodeOptions) ([][]string, error) {
+	m, err := parseMatrixMarket(r)
+	if err != nil {
+		return nil, err
+	}
+	return m.toCSR().toDense()
+}
+
+type xlsxWorkbookXML struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelsXML struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStringsXML struct {
+	Items []struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"si"`
+}
+
+type xlsxSheetXML struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// INFO: decompresses part of workbook, all parts share the budget of decodeOptions, so zip bomb can't
+// bypass the limit.
+func readZipXML(archive *zip.Reader, name string, left *int64, v any) error {
+	f, err := archive.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	limited := &limitedReader{r: f, left: *left}
+	err = xml.NewDecoder(limited).Decode(v)
+	*left = limited.left
+	return err
+}
+
+// INFO: any error except exceeded size limit means broken workbook.
+func xlsxError(err error) error {
+	if errors.Is(err, errExpandedTooLarge) {
+		return err
+	}
+	return errInvalidXLSX
+}
+
+// INFO: converts cell reference like "AB12" to 0-based row and column indexes, references outside of Excel
+// worksheet bounds are rejected.
+func parseCellRef(ref string) (int, int, error) {
+	col, i := 0, 0
+	for ; i < len(ref) && i <= xlsxMaxColLetters && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		col = col*26 + int(ref[i]-'A'+1)
+	}
+	row, err := strconv.Atoi(ref[i:])
+	if i == 0 || i > xlsxMaxColLetters || col > xlsxMaxCols || err != nil || row < 1 || row > xlsxMaxRows {
+		return 0, 0, fmt.Errorf("%w: invalid cell reference %q", errInvalidXLSX, ref)
+	}
+	return row - 1, col - 1, nil
+}
+
+// INFO: reads the first sheet of workbook or the one with name from options. Missing cells inside used range
+// are returned as empty strings.
+func decodeXLSX(r io.Reader, opts decodeOptions) ([][]string, error) {
+	content, err := io.ReadAll(&limitedReader{r: r, left: opts.maxExpandedSize})
+	if err != nil {
+		return nil, err
+	}
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errInvalidXLSX, err.Error())
+	}
+
+	left := opts.maxExpandedSize
+	var workbook xlsxWorkbookXML
+	var rels xlsxRelsXML
+	err = readZipXML(archive, xlsxWorkbook, &left, &workbook)
+	if err == nil {
+		err = readZipXML(archive, xlsxWorkbookRels, &left, &rels)
+	}
+	if err != nil {
+		return nil, xlsxError(err)
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, errUnknownSheet
+	}
+	relID := workbook.Sheets[0].ID
+	if opts.sheet != "" {
+		relID = ""
+		for _, s := range workbook.Sheets {
+			if s.Name == opts.sheet {
+				relID = s.ID
+			}
+		}
+		if relID == "" {
+			return nil, fmt.Errorf("%w: %q", errUnknownSheet, opts.sheet)
+		}
+	}
+	var sheetPath string
+	for _, rel := range rels.Relationships {
+		if rel.ID == relID {
+			sheetPath = rel.Target
+		}
+	}
+	if strings.HasPrefix(sheetPath, "/") {
+		sheetPath = strings.TrimPrefix(sheetPath, "/")
+	} else {
+		sheetPath = path.Join("xl", sheetPath)
+	}
+
+	// INFO: shared strings part is absent when workbook contains only numbers.
+	var shared xlsxSharedStringsXML
+	err = readZipXML(archive, xlsxSharedStrings, &left, &shared)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, xlsxError(err)
+	}
+	var sheet xlsxSheetXML
+	err = readZipXML(archive, sheetPath, &left, &sheet)
+	if err != nil {
+		return nil, xlsxError(err)
+	}
+
+	cells := make(map[[2]int]string)
+	rowsNumber, columnsNumber := 0, 0
+	for _, row := range sheet.Rows {
+		for _, c := range row.Cells {
+			i, j, err := parseCellRef(c.Ref)
+			if err != nil {
+				return nil, err
+			}
+			value := c.Value
+			switch c.Type {
+			case "s":
+				idx, err := strconv.Atoi(c.Value)
+				if err != nil || idx < 0 || idx >= len(shared.Items) {
+					return nil, fmt.Errorf("%w: invalid shared string index in %s", errInvalidXLSX, c.Ref)
+				}
+				item := shared.Items[idx]
+				value = item.Text
+				for _, run := range item.Runs {
+					value += run.Text
+				}
+			case "inlineStr":
+				value = c.Inline
+			}
+			cells[[2]int{i, j}] = value
+			if i >= rowsNumber {
+				rowsNumber = i + 1
+			}
+			if j >= columnsNumber {
+				columnsNumber = j + 1
+			}
+		}
+	}
+
+	if rowsNumber == 0 {
+		return nil, nil
+	}
+	// INFO: a single far cell makes used range huge, so its size is checked before allocation.
+	if rowsNumber > maxDenseCells/columnsNumber {
+		return nil, fmt.Errorf("%w, got %dx%d", errTooManyCells, rowsNumber, columnsNumber)
+	}
+	res := make([][]string, rowsNumber)
+	for i := range res {
+		res[i] = make([]string, columnsNumber)
+		for j := range res[i] {
+			res[i][j] = cells[[2]int{i, j}]
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_lookupDecoder(t *testing.T) {
+	tt := []struct {
+		name                string
+		providedFilename    string
+		providedContentType string
+		expectedDecoder     decoder
+		expectedErr         error
+	}{
+		{
+			name:                "fail: unknown extension and content type",
+			providedFilename:    "matrix.dat",
+			providedContentType: "application/octet-stream",
+			expectedDecoder:     nil,
+			expectedErr:         errFileExtension,
+		},
+		{
+			name:                "success: uppercase extension",
+			providedFilename:    "MATRIX.TSV",
+			providedContentType: "application/octet-stream",
+			expectedDecoder:     decodeTSV,
+			expectedErr:         nil,
+		},
+		{
+			name:                "success: extension wins over content type",
+			providedFilename:    "matrix.txt",
+			providedContentType: "text/csv",
+			expectedDecoder:     decodeText,
+			expectedErr:         nil,
+		},
+		{
+			name:                "success: content type with parameters",
+			providedFilename:    "matrix",
+			providedContentType: "text/csv; charset=utf-8",
+			expectedDecoder:     decodeCSV,
+			expectedErr:         nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := lookupDecoder(tc.providedFilename, tc.providedContentType)
+			assert.Equal(t, reflect.ValueOf(tc.expectedDecoder).Pointer(), reflect.ValueOf(res).Pointer())
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func Test_decodeText(t *testing.T) {
+	// INFO: rows are wider than 64KB default limit of bufio.Scanner.
+	wideRow := strings.Repeat("1 ", 40_000)
+	wideResult := strings.Fields(wideRow)
+
+	tt := []struct {
+		name           string
+		providedData   string
+		expectedResult [][]string
+		expectError    bool
+	}{
+		{
+			name:         "fail: different number of fields",
+			providedData: "1 2\n3\n",
+			expectError:  true,
+		},
+		{
+			name:           "success: whitespace separated",
+			providedData:   "1  2\t3\n\n4 5 6\n",
+			expectedResult: [][]string{{"1", "2", "3"}, {"4", "5", "6"}},
+		},
+		{
+			name:           "success: comma separated",
+			providedData:   "1, 2,3\n4,5 ,6",
+			expectedResult: [][]string{{"1", "2", "3"}, {"4", "5", "6"}},
+		},
+		{
+			name:           "success: wide rows with CRLF",
+			providedData:   wideRow + "\r\n" + wideRow,
+			expectedResult: [][]string{wideResult, wideResult},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := decodeText(strings.NewReader(tc.providedData), decodeOptions{})
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedResult, res)
+		})
+	}
+}
+
+func Test_decodeMatrixMarket(t *testing.T) {
+	file, err := os.Open(mtxPath)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	res, err := decodeMatrixMarket(file, decodeOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"2", "0", "1.5"}, {"0", "-1", "0"}, {"4", "0", "0"}}, res)
+}
+
+func Test_decodeXLSX(t *testing.T) {
+	tt := []struct {
+		name           string
+		providedSheet  string
+		expectedResult [][]string
+		expectedErr    error
+	}{
+		{
+			name:           "fail: unknown sheet",
+			providedSheet:  "Missing",
+			expectedResult: nil,
+			expectedErr:    errUnknownSheet,
+		},
+		{
+			name:           "success: first sheet",
+			providedSheet:  "",
+			expectedResult: [][]string{{"1", "2", "3"}, {"4", "5", "6"}, {"7", "8", "9"}},
+			expectedErr:    nil,
+		},
+		{
+			name:           "success: named sheet with rich text and missing cells",
+			providedSheet:  "Labels",
+			expectedResult: [][]string{{"a", "bc"}, {"", "1"}},
+			expectedErr:    nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			file, err := os.Open(xlsxPath)
+			assert.NoError(t, err)
+			defer file.Close()
+
+			res, err := decodeXLSX(file, decodeOptions{sheet: tc.providedSheet, maxExpandedSize: defaultMaxExpandedSize})
+			assert.Equal(t, tc.expectedResult, res)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+
+	t.Run("fail: not a zip archive", func(t *testing.T) {
+		_, err := decodeXLSX(strings.NewReader("1,2\n3,4\n"), decodeOptions{maxExpandedSize: defaultMaxExpandedSize})
+		assert.ErrorIs(t, err, errInvalidXLSX)
+	})
+
+	t.Run("fail: used range is too large", func(t *testing.T) {
+		sheet := `<worksheet><sheetData><row r="1"><c r="A1"><v>1</v></c></row>` +
+			`<row r="1048576"><c r="XFD1048576"><v>1</v></c></row></sheetData></worksheet>`
+		_, err := decodeXLSX(bytes.NewReader(xlsxWithSheet(t, sheet)), decodeOptions{maxExpandedSize: defaultMaxExpandedSize})
+		assert.ErrorIs(t, err, errTooManyCells)
+	})
+
+	t.Run("fail: sheet is decompressed beyond limit", func(t *testing.T) {
+		sheet := `<worksheet><sheetData>` + strings.Repeat(" ", 1<<20) + `</sheetData></worksheet>`
+		_, err := decodeXLSX(bytes.NewReader(xlsxWithSheet(t, sheet)), decodeOptions{maxExpandedSize: 1 << 14})
+		assert.ErrorIs(t, err, errExpandedTooLarge)
+	})
+}
+
+// INFO: copies test workbook replacing content of its first sheet.
+func xlsxWithSheet(t *testing.T, sheet string) []byte {
+	t.Helper()
+	src, err := zip.OpenReader(xlsxPath)
+	assert.NoError(t, err)
+	defer src.Close()
+
+	buf := new(bytes.Buffer)
+	dst := zip.NewWriter(buf)
+	for _, f := range src.File {
+		w, err := dst.Create(f.Name)
+		assert.NoError(t, err)
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			_, err = io.WriteString(w, sheet)
+			assert.NoError(t, err)
+			continue
+		}
+		r, err := f.Open()
+		assert.NoError(t, err)
+		_, err = io.Copy(w, r)
+		assert.NoError(t, err)
+		assert.NoError(t, r.Close())
+	}
+	assert.NoError(t, dst.Close())
+	return buf.Bytes()
+}
+
+func Test_parseCellRef(t *testing.T) {
+	tt := []struct {
+		name        string
+		providedRef string
+		expectedRow int
+		expectedCol int
+		expectedErr error
+	}{
+		{
+			name:        "fail: no row",
+			providedRef: "AB",
+			expectedErr: errInvalidXLSX,
+		},
+		{
+			name:        "fail: no column",
+			providedRef: "12",
+			expectedErr: errInvalidXLSX,
+		},
+		{
+			name:        "success: single letter",
+			providedRef: "C1",
+			expectedRow: 0,
+			expectedCol: 2,
+		},
+		{
+			name:        "success: two letters",
+			providedRef: "AB12",
+			expectedRow: 11,
+			expectedCol: 27,
+		},
+		{
+			name:        "fail: more than three letters",
+			providedRef: "AAAA1",
+			expectedErr: errInvalidXLSX,
+		},
+		{
+			name:        "fail: column after XFD",
+			providedRef: "XFE1",
+			expectedErr: errInvalidXLSX,
+		},
+		{
+			name:        "fail: row after 1048576",
+			providedRef: "A1048577",
+			expectedErr: errInvalidXLSX,
+		},
+		{
+			name:        "success: last cell of sheet",
+			providedRef: "XFD1048576",
+			expectedRow: 1048575,
+			expectedCol: 16383,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			row, col, err := parseCellRef(tc.providedRef)
+			assert.Equal(t, tc.expectedRow, row)
+			assert.Equal(t, tc.expectedCol, col)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+const (
+	methodKey  = "method"
+	tolKey     = "tol"
+	maxIterKey = "max_iter"
+
+	methodLU       = "lu"
+	methodQR       = "qr"
+	methodCholesky = "cholesky"
+	methodEigen    = "eigen"
+	methodSVD      = "svd"
+
+	formatZip = "zip"
+
+	valuesFileName = "values.csv"
+)
+
+var (
+	errUnknownMethod    = errors.New("unknown method, should be one of \"lu\", \"qr\", \"cholesky\", \"eigen\", \"svd\"")
+	errInvalidZipFormat = errors.New("invalid output format, should be one of \"json\", \"zip\"")
+)
+
+// INFO: result of decomposition, component matrices are keyed by their conventional names (P, L, U, Q, R,
+// V, ...). Values are eigenvalues or singular values.
+type decomposition struct {
+	Method   string                 `json:"method"`
+	Matrices map[string][][]float64 `json:"matrices"`
+	Values   []float64              `json:"values,omitempty"`
+}
+
+// INFO: reads tolerance and iterations limit, both are optional.
+func parseNumericParams(r *http.Request) (float64, int, error) {
+	tol, maxIter := defaultTolerance, defaultMaxIter
+	if r.FormValue(tolKey) != "" {
+		v, err := floatParam(r, tolKey)
+		if err != nil || v <= 0 {
+			return 0, 0, fmt.Errorf("%w %q: positive number expected", errInvalidParam, tolKey)
+		}
+		tol = v
+	}
+	if r.FormValue(maxIterKey) != "" {
+		v, err := strconv.Atoi(r.FormValue(maxIterKey))
+		if err != nil || v <= 0 {
+			return 0, 0, fmt.Errorf("%w %q: positive integer expected", errInvalidParam, maxIterKey)
+		}
+		maxIter = v
+	}
+	return tol, maxIter, nil
+}
+
+// INFO: replaces values smaller than tolerance with 0.
+func snap(a [][]float64, tol float64) [][]float64 {
+	for i := range a {
+		for j := range a[i] {
+			if math.Abs(a[i][j]) < tol {
+				a[i][j] = 0
+			}
+		}
+	}
+	return a
+}
+
+func decomposeMatrix(ctx context.Context, a [][]float64, method string, tol float64, maxIter int) (decomposition, error) {
+	square := len(a) == len(a[0])
+	res := decomposition{Method: method, Matrices: make(map[string][][]float64)}
+
+	switch method {
+	case methodLU:
+		if !square {
+			return decomposition{}, errMatrixNotSquare
+		}
+		perm, l, u, _ := luDecompose(a)
+		res.Matrices["P"] = permutationMatrix(perm)
+		res.Matrices["L"] = l
+		res.Matrices["U"] = u
+	case methodQR:
+		q, r, err := qrDecompose(ctx, a)
+		if err != nil {
+			return decomposition{}, err
+		}
+		res.Matrices["Q"] = q
+		res.Matrices["R"] = r
+	case methodCholesky:
+		if !square {
+			return decomposition{}, errMatrixNotSquare
+		}
+		l, err := choleskyDecompose(a, tol)
+		if err != nil {
+			return decomposition{}, err
+		}
+		res.Matrices["L"] = l
+	case methodEigen:
+		if !square {
+			return decomposition{}, errMatrixNotSquare
+		}
+		values, vectors, err := symmetricEigen(ctx, a, tol, maxIter)
+		if err != nil {
+			return decomposition{}, err
+		}
+		res.Matrices["V"] = vectors
+		res.Values = values
+	case methodSVD:
+		// INFO: one-sided Jacobi needs at least as many rows as columns, wide matrix is decomposed transposed.
+		wide := len(a) < len(a[0])
+		if wide {
+			var err error
+			a, err = transposeDense(ctx, a)
+			if err != nil {
+				return decomposition{}, err
+			}
+		}
+		u, s, v, err := svdDecompose(ctx, a, tol, maxIter)
+		if err != nil {
+			return decomposition{}, err
+		}
+		if wide {
+			u, v = v, u
+		}
+		res.Matrices["U"] = u
+		res.Matrices["V"] = v
+		res.Values = s
+	default:
+		return decomposition{}, errUnknownMethod
+	}
+
+	for name := range res.Matrices {
+		res.Matrices[name] = snap(res.Matrices[name], tol)
+	}
+	for i := range res.Values {
+		if math.Abs(res.Values[i]) < tol {
+			res.Values[i] = 0
+		}
+	}
+	return res, nil
+}
+
+// INFO: writes each component matrix as "<name>.csv" file and values as a single row "values.csv".
+func writeDecompositionZip(w http.ResponseWriter, d decomposition, tol float64) error {
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", d.Method+".zip"))
+	w.WriteHeader(http.StatusOK)
+
+	archive := zip.NewWriter(w)
+	for _, name := range sortedKeys(d.Matrices) {
+		f, err := archive.Create(name + csvExt)
+		if err != nil {
+			return err
+		}
+		err = csv.NewWriter(f).WriteAll(denseToMatrix(d.Matrices[name], tol))
+		if err != nil {
+			return err
+		}
+	}
+	if d.Values != nil {
+		f, err := archive.Create(valuesFileName)
+		if err != nil {
+			return err
+		}
+		err = csv.NewWriter(f).WriteAll(denseToMatrix([][]float64{d.Values}, tol))
+		if err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// INFO: maps numerical errors to response status. Invalid data is a bad request, matrices which don't satisfy
+// method requirements are reported as unprocessable.
+func numericErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errMatrixNotSquare), errors.Is(err, errUnknownMethod), errors.Is(err, errNotNumber),
+		errors.Is(err, errNotFinite):
+		return http.StatusBadRequest
+	case errors.Is(err, errNotSymmetric), errors.Is(err, errNotPositiveDefinite), errors.Is(err, errNoConvergence):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (rout *Router) Decompose(w http.ResponseWriter, r *http.Request) {
+	matrix, err := rout.parseMatrix(r, fileKey)
+	if err != nil {
+		rout.log.Error("extracting data from .csv file failed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	method := r.FormValue(methodKey)
+	tol, maxIter, err := parseNumericParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format := r.FormValue(formatKey)
+	if format != "" && format != formatJSON && format != formatZip {
+		http.Error(w, errInvalidZipFormat.Error(), http.StatusBadRequest)
+		return
+	}
+
+	a, err := matrixToFloat(matrix)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := decomposeMatrix(r.Context(), a, method, tol, maxIter)
+	rout.log.Info("Decompose command called", zap.String("method", method))
+	if err != nil {
+		http.Error(w, err.Error(), numericErrorStatus(err))
+		return
+	}
+
+	if format == formatZip {
+		err = writeDecompositionZip(w, res, tol)
+	} else {
+		err = writeJSON(w, http.StatusOK, res)
+	}
+	if err != nil {
+		rout.log.Error("writing response failed", zap.Error(err))
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const (
+	symmetricPath = "./data/symmetric.csv"
+)
+
+func Test_parseNumericParams(t *testing.T) {
+	tt := []struct {
+		name            string
+		providedURL     string
+		expectedTol     float64
+		expectedMaxIter int
+		expectedErr     error
+	}{
+		{
+			name:            "fail: negative tolerance",
+			providedURL:     testURL + "?tol=-1",
+			expectedTol:     0,
+			expectedMaxIter: 0,
+			expectedErr:     errInvalidParam,
+		},
+		{
+			name:            "fail: invalid iterations limit",
+			providedURL:     testURL + "?max_iter=many",
+			expectedTol:     0,
+			expectedMaxIter: 0,
+			expectedErr:     errInvalidParam,
+		},
+		{
+			name:            "success: defaults",
+			providedURL:     testURL,
+			expectedTol:     defaultTolerance,
+			expectedMaxIter: defaultMaxIter,
+			expectedErr:     nil,
+		},
+		{
+			name:            "success: params parsed",
+			providedURL:     testURL + "?tol=1e-6&max_iter=10",
+			expectedTol:     1e-6,
+			expectedMaxIter: 10,
+			expectedErr:     nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.providedURL, nil)
+			tol, maxIter, err := parseNumericParams(req)
+			assert.Equal(t, tc.expectedTol, tol)
+			assert.Equal(t, tc.expectedMaxIter, maxIter)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func Test_decomposeMatrix(t *testing.T) {
+	square := [][]float64{{4, 12, -16}, {12, 37, -43}, {-16, -43, 98}}
+	wide := [][]float64{{3, 2, 2}, {2, 3, -2}}
+
+	tt := []struct {
+		name           string
+		providedA      [][]float64
+		providedMethod string
+		expectedNames  []string
+		expectedValues int
+		expectedErr    error
+	}{
+		{
+			name:           "fail: unknown method",
+			providedA:      square,
+			providedMethod: "schur",
+			expectedErr:    errUnknownMethod,
+		},
+		{
+			name:           "fail: lu of non-square matrix",
+			providedA:      wide,
+			providedMethod: methodLU,
+			expectedErr:    errMatrixNotSquare,
+		},
+		{
+			name:           "success: lu",
+			providedA:      square,
+			providedMethod: methodLU,
+			expectedNames:  []string{"L", "P", "U"},
+		},
+		{
+			name:           "success: qr of non-square matrix",
+			providedA:      wide,
+			providedMethod: methodQR,
+			expectedNames:  []string{"Q", "R"},
+		},
+		{
+			name:           "success: cholesky",
+			providedA:      square,
+			providedMethod: methodCholesky,
+			expectedNames:  []string{"L"},
+		},
+		{
+			name:           "success: eigen",
+			providedA:      square,
+			providedMethod: methodEigen,
+			expectedNames:  []string{"V"},
+			expectedValues: 3,
+		},
+		{
+			name:           "success: svd of wide matrix",
+			providedA:      wide,
+			providedMethod: methodSVD,
+			expectedNames:  []string{"U", "V"},
+			expectedValues: 2,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := decomposeMatrix(context.Background(), cloneDense(tc.providedA), tc.providedMethod, defaultTolerance, defaultMaxIter)
+			assert.ErrorIs(t, err, tc.expectedErr)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.expectedNames, sortedKeys(res.Matrices))
+			assert.Len(t, res.Values, tc.expectedValues)
+		})
+	}
+
+	t.Run("success: wide svd reconstructs matrix", func(t *testing.T) {
+		res, err := decomposeMatrix(context.Background(), cloneDense(wide), methodSVD, defaultTolerance, defaultMaxIter)
+		assert.NoError(t, err)
+		u, v := res.Matrices["U"], res.Matrices["V"]
+		assertDenseEqual(t, wide, mustMulDense(t, mustMulDense(t, u, diag(res.Values)), mustTransposeDense(t, v)))
+	})
+}
+
+func TestRouter_Decompose(t *testing.T) {
+	validBody := `{"method":"cholesky","matrices":{"L":[[2,0,0],[6,1,0],[-8,5,3]]}}` + "\n"
+	router, err := setupRouter()
+	assert.NoError(t, err)
+
+	successReq, successW, err := createReq(symmetricPath, testURL+"?method=cholesky")
+	assert.NoError(t, err)
+	successReq.Header.Set("Content-Type", successW.FormDataContentType())
+
+	notSymmetricReq, notSymmetricW, err := createReq(validPath, testURL+"?method=cholesky")
+	assert.NoError(t, err)
+	notSymmetricReq.Header.Set("Content-Type", notSymmetricW.FormDataContentType())
+
+	notSymmetricEigenReq, notSymmetricEigenW, err := createReq(validPath, testURL+"?method=eigen")
+	assert.NoError(t, err)
+	notSymmetricEigenReq.Header.Set("Content-Type", notSymmetricEigenW.FormDataContentType())
+
+	notSquareReq, notSquareW, err := createReq(notSquarePath, testURL+"?method=eigen")
+	assert.NoError(t, err)
+	notSquareReq.Header.Set("Content-Type", notSquareW.FormDataContentType())
+
+	notNumberReq, notNumberW, err := createReq(notNumberPath, testURL+"?method=lu")
+	assert.NoError(t, err)
+	notNumberReq.Header.Set("Content-Type", notNumberW.FormDataContentType())
+
+	unknownReq, unknownW, err := createReq(validPath, testURL+"?method=schur")
+	assert.NoError(t, err)
+	unknownReq.Header.Set("Content-Type", unknownW.FormDataContentType())
+
+	tt := []struct {
+		name         string
+		providedReq  *http.Request
+		expectedBody string
+		expectedCode int
+	}{
+		{
+			name:         "fail: unknown method - BadRequest",
+			providedReq:  unknownReq,
+			expectedBody: errUnknownMethod.Error() + "\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "fail: not square matrix - BadRequest",
+			providedReq:  notSquareReq,
+			expectedBody: errMatrixNotSquare.Error() + "\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "fail: not a number - BadRequest",
+			providedReq:  notNumberReq,
+			expectedBody: errNotNumber.Error() + "\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "fail: not symmetric matrix - UnprocessableEntity",
+			providedReq:  notSymmetricReq,
+			expectedBody: errNotSymmetric.Error() + "\n",
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "fail: eigen of not symmetric matrix - UnprocessableEntity",
+			providedReq:  notSymmetricEigenReq,
+			expectedBody: errEigenNotSymmetric.Error() + "\n",
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "success: decomposed",
+			providedReq:  successReq,
+			expectedBody: validBody,
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.Decompose(w, tc.providedReq)
+
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestRouter_Decompose_zip(t *testing.T) {
+	router, err := setupRouter()
+	assert.NoError(t, err)
+
+	req, writer, err := createReq(symmetricPath, testURL+"?method=eigen&format=zip")
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	w := httptest.NewRecorder()
+	router.Decompose(w, req)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+
+	body := w.Body.Bytes()
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	assert.NoError(t, err)
+
+	var names []string
+	for _, f := range archive.File {
+		names = append(names, f.Name)
+		rc, err := f.Open()
+		assert.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, content)
+		assert.NoError(t, rc.Close())
+	}
+	assert.Equal(t, []string{"V.csv", valuesFileName}, names)
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+const (
+	opKey     = "op"
+	valueKey  = "value"
+	minKey    = "min"
+	maxKey    = "max"
+	placesKey = "places"
+
+	// INFO: float64 keeps about 15 significant decimal digits, more places don't change the value.
+	maxRoundPlaces = 15
+)
+
+var (
+	errUnknownMapOperation = errors.New("unknown map operation, should be one of \"add\", \"multiply\", " +
+		"\"divide\", \"power\", \"abs\", \"round\", \"clamp\", \"minmax\", \"zscore\"")
+	errInvalidParam   = errors.New("invalid parameter")
+	errDivisionByZero = errors.New("division by zero")
+	errNotFinite      = errors.New("result is not a finite number")
+	errInvalidPlaces  = fmt.Errorf("%w %q: integer from 0 to %d expected", errInvalidParam, placesKey, maxRoundPlaces)
+)
+
+// INFO: parameters of element-wise operations, each operation uses only its own subset of them.
+type mapParams struct {
+	value  float64
+	min    float64
+	max    float64
+	places int
+}
+
+// INFO: mapper builds element function for the whole matrix. Matrix is required because normalizations
+// depend on its statistics.
+type mapper func(matrix [][]float64, params mapParams) func(x float64) float64
+
+var mappers = map[string]mapper{
+	"add": func(_ [][]float64, params mapParams) func(x float64) float64 {
+		return func(x float64) float64 { return x + params.value }
+	},
+	"multiply": func(_ [][]float64, params mapParams) func(x float64) float64 {
+		return func(x float64) float64 { return x * params.value }
+	},
+	"divide": func(_ [][]float64, params mapParams) func(x float64) float64 {
+		return func(x float64) float64 { return x / params.value }
+	},
+	"power": func(_ [][]float64, params mapParams) func(x float64) float64 {
+		return func(x float64) float64 { return math.Pow(x, params.value) }
+	},
+	"abs": func(_ [][]float64, _ mapParams) func(x float64) float64 {
+		return math.Abs
+	},
+	"round": func(_ [][]float64, params mapParams) func(x float64) float64 {
+		scale := math.Pow(10, float64(params.places))
+		return func(x float64) float64 { return math.Round(x*scale) / scale }
+	},
+	"clamp": func(_ [][]float64, params mapParams) func(x float64) float64 {
+		return func(x float64) float64 { return math.Max(params.min, math.Min(params.max, x)) }
+	},
+	"minmax": func(matrix [][]float64, _ mapParams) func(x float64) float64 {
+		acc := accumulate(matrix)
+		if acc.max == acc.min {
+			return func(float64) float64 { return 0 }
+		}
+		return func(x float64) float64 { return (x - acc.min) / (acc.max - acc.min) }
+	},
+	"zscore": func(matrix [][]float64, _ mapParams) func(x float64) float64 {
+		acc := accumulate(matrix)
+		std := math.Sqrt(acc.variance())
+		if std == 0 {
+			return func(float64) float64 { return 0 }
+		}
+		return func(x float64) float64 { return (x - acc.mean) / std }
+	},
+}
+
+func accumulate(matrix [][]float64) welford {
+	var acc welford
+	for _, row := range matrix {
+		for _, v := range row {
+			acc.add(v)
+		}
+	}
+	return acc
+}
+
+// INFO: reads float parameter from the request form.
+func floatParam(r *http.Request, key string) (float64, error) {
+	v, err := strconv.ParseFloat(r.FormValue(key), 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("%w %q: number expected", errInvalidParam, key)
+	}
+	return v, nil
+}
+
+// INFO: reads only parameters required by the operation and validates them.
+func parseMapParams(r *http.Request, op string) (mapParams, error) {
+	var (
+		params mapParams
+		err    error
+	)
+	switch op {
+	case "add", "multiply", "power":
+		params.value, err = floatParam(r, valueKey)
+	case "divide":
+		params.value, err = floatParam(r, valueKey)
+		if err == nil && params.value == 0 {
+			err = errDivisionByZero
+		}
+	case "round":
+		if r.FormValue(placesKey) != "" {
+			params.places, err = strconv.Atoi(r.FormValue(placesKey))
+			if err != nil || params.places < 0 || params.places > maxRoundPlaces {
+				params.places, err = 0, errInvalidPlaces
+			}
+		}
+	case "clamp":
+		params.min, err = floatParam(r, minKey)
+		if err == nil {
+			params.max, err = floatParam(r, maxKey)
+		}
+		if err == nil && params.min > params.max {
+			err = fmt.Errorf("%w: %q is greater than %q", errInvalidParam, minKey, maxKey)
+		}
+	}
+	return params, err
+}
+
+// INFO: applies operation to each element. Result is formatted without trailing zeros, so integer results
+// look the same as input values.
+func mapMatrix(matrix [][]string, op string, params mapParams) ([][]string, error) {
+	m, ok := mappers[op]
+	if !ok {
+		return nil, errUnknownMapOperation
+	}
+
+	floatMatrix, err := matrixToFloat(matrix)
+	if err != nil {
+		return nil, err
+	}
+	fn := m(floatMatrix, params)
+
+	res := make([][]string, len(floatMatrix))
+	for i, row := range floatMatrix {
+		res[i] = make([]string, len(row))
+		for j, v := range row {
+			v = fn(v)
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return nil, errNotFinite
+			}
+			res[i][j] = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+
+	return res, nil
+}
+
+func (rout *Router) Map(w http.ResponseWriter, r *http.Request) {
+	matrix, err := rout.extractData(r, fileKey)
+	if err != nil {
+		rout.log.Error("extracting data from .csv file failed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	op := r.FormValue(opKey)
+	if _, ok := mappers[op]; !ok {
+		http.Error(w, errUnknownMapOperation.Error(), http.StatusBadRequest)
+		return
+	}
+	params, err := parseMapParams(r, op)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mapped, err := mapMatrix(matrix, op, params)
+	rout.log.Info("Map command called", zap.String("op", op))
+	if err != nil {
+		http.Error(w, err.Error(), numericErrorStatus(err))
+		return
+	}
+	response := convertToMatrixString(mapped)
+	w.WriteHeader(http.StatusOK)
+
+	_, err = fmt.Fprint(w, response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_parseMapParams(t *testing.T) {
+	tt := []struct {
+		name           string
+		providedURL    string
+		providedOp     string
+		expectedResult mapParams
+		expectedErr    error
+	}{
+		{
+			name:           "fail: missing value",
+			providedURL:    testURL,
+			providedOp:     "add",
+			expectedResult: mapParams{},
+			expectedErr:    errInvalidParam,
+		},
+		{
+			name:           "fail: division by zero",
+			providedURL:    testURL + "?value=0",
+			providedOp:     "divide",
+			expectedResult: mapParams{},
+			expectedErr:    errDivisionByZero,
+		},
+		{
+			name:           "fail: min greater than max",
+			providedURL:    testURL + "?min=5&max=1",
+			providedOp:     "clamp",
+			expectedResult: mapParams{min: 5, max: 1},
+			expectedErr:    errInvalidParam,
+		},
+		{
+			name:           "fail: round places out of range",
+			providedURL:    testURL + "?places=400",
+			providedOp:     "round",
+			expectedResult: mapParams{},
+			expectedErr:    errInvalidPlaces,
+		},
+		{
+			name:           "fail: negative round places",
+			providedURL:    testURL + "?places=-1",
+			providedOp:     "round",
+			expectedResult: mapParams{},
+			expectedErr:    errInvalidPlaces,
+		},
+		{
+			name:           "success: round places parsed",
+			providedURL:    testURL + "?places=2",
+			providedOp:     "round",
+			expectedResult: mapParams{places: 2},
+			expectedErr:    nil,
+		},
+		{
+			name:           "success: clamp bounds parsed",
+			providedURL:    testURL + "?min=-1&max=1.5",
+			providedOp:     "clamp",
+			expectedResult: mapParams{min: -1, max: 1.5},
+			expectedErr:    nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.providedURL, nil)
+			res, err := parseMapParams(req, tc.providedOp)
+			assert.Equal(t, tc.expectedResult, res)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func Test_mapMatrix(t *testing.T) {
+	validMatrix := [][]string{{"1", "2"}, {"3", "4"}}
+	negativeMatrix := [][]string{{"-1.25", "2"}, {"-3", "4.75"}}
+	invalidMatrix := [][]string{{"1", "b"}, {"3", "4"}}
+
+	tt := []struct {
+		name           string
+		providedMatrix [][]string
+		providedOp     string
+		providedParams mapParams
+		expectedResult [][]string
+		expectedErr    error
+	}{
+		{
+			name:           "fail: unknown operation",
+			providedMatrix: validMatrix,
+			providedOp:     "sqrt",
+			expectedResult: nil,
+			expectedErr:    errUnknownMapOperation,
+		},
+		{
+			name:           "fail: matrix with non-numeric values",
+			providedMatrix: invalidMatrix,
+			providedOp:     "abs",
+			expectedResult: nil,
+			expectedErr:    errNotNumber,
+		},
+		{
+			name:           "fail: not finite result",
+			providedMatrix: negativeMatrix,
+			providedOp:     "power",
+			providedParams: mapParams{value: 0.5},
+			expectedResult: nil,
+			expectedErr:    errNotFinite,
+		},
+		{
+			name:           "success: scalar add",
+			providedMatrix: validMatrix,
+			providedOp:     "add",
+			providedParams: mapParams{value: 0.5},
+			expectedResult: [][]string{{"1.5", "2.5"}, {"3.5", "4.5"}},
+		},
+		{
+			name:           "success: scalar divide",
+			providedMatrix: validMatrix,
+			providedOp:     "divide",
+			providedParams: mapParams{value: 2},
+			expectedResult: [][]string{{"0.5", "1"}, {"1.5", "2"}},
+		},
+		{
+			name:           "success: power",
+			providedMatrix: validMatrix,
+			providedOp:     "power",
+			providedParams: mapParams{value: 2},
+			expectedResult: [][]string{{"1", "4"}, {"9", "16"}},
+		},
+		{
+			name:           "success: absolute value",
+			providedMatrix: negativeMatrix,
+			providedOp:     "abs",
+			expectedResult: [][]string{{"1.25", "2"}, {"3", "4.75"}},
+		},
+		{
+			name:           "success: round to one place",
+			providedMatrix: negativeMatrix,
+			providedOp:     "round",
+			providedParams: mapParams{places: 1},
+			expectedResult: [][]string{{"-1.3", "2"}, {"-3", "4.8"}},
+		},
+		{
+			name:           "success: clamp",
+			providedMatrix: validMatrix,
+			providedOp:     "clamp",
+			providedParams: mapParams{min: 2, max: 3},
+			expectedResult: [][]string{{"2", "2"}, {"3", "3"}},
+		},
+		{
+			name:           "success: min-max normalization",
+			providedMatrix: [][]string{{"0", "5"}, {"10", "2.5"}},
+			providedOp:     "minmax",
+			expectedResult: [][]string{{"0", "0.5"}, {"1", "0.25"}},
+		},
+		{
+			name:           "success: z-score normalization",
+			providedMatrix: [][]string{{"2", "4"}, {"4", "6"}},
+			providedOp:     "zscore",
+			expectedResult: [][]string{{"-1.414213562373095", "0"}, {"0", "1.414213562373095"}},
+		},
+		{
+			name:           "success: normalization of constant matrix",
+			providedMatrix: [][]string{{"7", "7"}, {"7", "7"}},
+			providedOp:     "zscore",
+			expectedResult: [][]string{{"0", "0"}, {"0", "0"}},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := mapMatrix(tc.providedMatrix, tc.providedOp, tc.providedParams)
+			assert.Equal(t, tc.expectedResult, res)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestRouter_Map(t *testing.T) {
+	router, err := setupRouter()
+	assert.NoError(t, err)
+
+	successReq, successW, err := createReq(validPath, testURL+"?op=multiply&value=-2")
+	assert.NoError(t, err)
+	successReq.Header.Set("Content-Type", successW.FormDataContentType())
+
+	unknownReq, unknownW, err := createReq(validPath, testURL+"?op=sqrt")
+	assert.NoError(t, err)
+	unknownReq.Header.Set("Content-Type", unknownW.FormDataContentType())
+
+	zeroReq, zeroW, err := createReq(validPath, testURL+"?op=divide&value=0")
+	assert.NoError(t, err)
+	zeroReq.Header.Set("Content-Type", zeroW.FormDataContentType())
+
+	notNumberReq, notNumberW, err := createReq(notNumberPath, testURL+"?op=abs")
+	assert.NoError(t, err)
+	notNumberReq.Header.Set("Content-Type", notNumberW.FormDataContentType())
+
+	infiniteReq, infiniteW, err := createReq(validPath, testURL+"?op=multiply&value=1e308")
+	assert.NoError(t, err)
+	infiniteReq.Header.Set("Content-Type", infiniteW.FormDataContentType())
+
+	unsupportedReq, unsupportedW, err := createReq(unsupportedPath, testURL+"?op=abs")
+	assert.NoError(t, err)
+	unsupportedReq.Header.Set("Content-Type", unsupportedW.FormDataContentType())
+
+	tt := []struct {
+		name         string
+		providedReq  *http.Request
+		expectedBody string
+		expectedCode int
+	}{
+		{
+			name:         "fail: extract data - BadRequest",
+			providedReq:  unsupportedReq,
+			expectedBody: errFileExtension.Error() + "\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "fail: unknown operation - BadRequest",
+			providedReq:  unknownReq,
+			expectedBody: errUnknownMapOperation.Error() + "\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "fail: division by zero - BadRequest",
+			providedReq:  zeroReq,
+			expectedBody: errDivisionByZero.Error() + "\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "fail: not a number - BadRequest",
+			providedReq:  notNumberReq,
+			expectedBody: errNotNumber.Error() + "\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "fail: infinite result - BadRequest",
+			providedReq:  infiniteReq,
+			expectedBody: errNotFinite.Error() + "\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "success: mapped matrix",
+			providedReq:  successReq,
+			expectedBody: "-2,-4,-6\n-8,-10,-12\n-14,-16,-18\n",
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.Map(w, tc.providedReq)
+
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
@@ -1,8 +1,9 @@
 module challenge
 
-go 1.20
+go 1.22
 
 require (
+	github.com/klauspost/compress v1.18.0
 	github.com/stretchr/testify v1.8.0
 	go.uber.org/zap v1.24.0
 )
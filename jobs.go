@@ -0,0 +1,592 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	jobs            = "/jobs"
+	jobsPrefix      = "/jobs/"
+	jobResultSuffix = "result"
+
+	jobQueued   = "queued"
+	jobRunning  = "running"
+	jobDone     = "done"
+	jobFailed   = "failed"
+	jobCanceled = "canceled"
+
+	defaultJobWorkers   = 4
+	defaultJobQueueSize = 64
+	defaultJobTTL       = time.Hour
+	// INFO: expired jobs are removed from memory not more often than this, Load hides them anyway.
+	jobSweepInterval = time.Minute
+)
+
+var (
+	errJobNotFound    = errors.New("job not found")
+	errJobNotFinished = errors.New("job is not finished yet")
+	errJobQueueFull   = errors.New("job queue is full, try again later")
+	errJobsStopped    = errors.New("job workers are stopped")
+	errJobOperation   = errors.New("invalid job operation, use \"op\" parameter with end-point name, e.g. \"invert\"")
+	errJobPath        = errors.New("invalid job path, should be \"/jobs/{id}\" or \"/jobs/{id}/result\"")
+)
+
+// INFO: Job is asynchronous call of one of the end-points. Result is kept in the store together with the job
+// state, but isn't a part of status response. Progress is the share of completed row blocks of the
+// computation in range [0, 1]. Only the principal who submitted the job (Owner) can see and cancel it.
+type Job struct {
+	ID         string     `json:"id"`
+	Operation  string     `json:"operation"`
+	Status     string     `json:"status"`
+	Progress   float64    `json:"progress"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Owner      string     `json:"-"`
+	Result     *JobResult `json:"-"`
+}
+
+// INFO: JobResult is the response end-point produced for the job.
+type JobResult struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+func (j Job) finished() bool {
+	return j.Status == jobDone || j.Status == jobFailed
+}
+
+// INFO: JobStore keeps jobs between submission and result download, implementations must be safe for
+// concurrent use and return errJobNotFound for unknown or expired jobs.
+type JobStore interface {
+	Save(job Job) error
+	Load(id string) (Job, error)
+	Delete(id string) error
+}
+
+// INFO: memoryJobStore is the default JobStore, finished jobs are dropped after ttl.
+type memoryJobStore struct {
+	mu        sync.Mutex
+	jobs      map[string]Job
+	ttl       time.Duration
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func newMemoryJobStore(ttl time.Duration) *memoryJobStore {
+	return &memoryJobStore{jobs: map[string]Job{}, ttl: ttl, now: time.Now}
+}
+
+func (s *memoryJobStore) expired(job Job) bool {
+	return job.finished() && s.now().Sub(*job.FinishedAt) > s.ttl
+}
+
+func (s *memoryJobStore) Save(job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep()
+	s.jobs[job.ID] = job
+	return nil
+}
+
+func (s *memoryJobStore) sweep() {
+	now := s.now()
+	if now.Sub(s.lastSweep) < jobSweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for id, stored := range s.jobs {
+		if s.expired(stored) {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+func (s *memoryJobStore) Load(id string) (Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok || s.expired(job) {
+		return Job{}, errJobNotFound
+	}
+	return job, nil
+}
+
+func (s *memoryJobStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.jobs[id]; !ok {
+		return errJobNotFound
+	}
+	delete(s.jobs, id)
+	return nil
+}
+
+// INFO: bufferedResponse collects end-point response in memory to be saved as job result.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBufferedResponse() *bufferedResponse {
+	return &bufferedResponse{header: http.Header{}}
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+	return b.body.Write(p)
+}
+
+type jobContextKey struct{}
+
+type jobProgressKey struct{}
+
+// INFO: jobProgress counts row blocks of the job computation, parallel helpers report to it through the
+// request context. Methods are no-op for nil, so requests outside of jobs don't need it.
+type jobProgress struct {
+	total int64
+	done  int64
+}
+
+func progressFrom(ctx context.Context) *jobProgress {
+	p, _ := ctx.Value(jobProgressKey{}).(*jobProgress)
+	return p
+}
+
+func (p *jobProgress) add(blocks int) {
+	if p != nil {
+		atomic.AddInt64(&p.total, int64(blocks))
+	}
+}
+
+func (p *jobProgress) finish() {
+	if p != nil {
+		atomic.AddInt64(&p.done, 1)
+	}
+}
+
+// INFO: share of completed blocks, the total grows when computation starts its next parallel step.
+func (p *jobProgress) ratio() float64 {
+	total := atomic.LoadInt64(&p.total)
+	if total == 0 {
+		return 0
+	}
+	return float64(atomic.LoadInt64(&p.done)) / float64(total)
+}
+
+// INFO: requests made by job workers are already limited by the worker pool and were rate limited on
+// submission.
+func isJobRequest(r *http.Request) bool {
+	v, _ := r.Context().Value(jobContextKey{}).(bool)
+	return v
+}
+
+type jobTask struct {
+	id  string
+	req *http.Request
+}
+
+// INFO: jobManager runs jobs on a bounded pool of workers, submission fails when the queue is full instead of
+// piling up requests in memory.
+type jobManager struct {
+	mux       *http.ServeMux
+	store     JobStore
+	workers   int
+	queueSize int
+
+	mu       sync.Mutex
+	queue    chan jobTask
+	cancels  map[string]context.CancelFunc
+	progress map[string]*jobProgress
+	stopped  bool
+	wg       sync.WaitGroup
+}
+
+func newJobManager(mux *http.ServeMux) *jobManager {
+	return &jobManager{
+		mux:       mux,
+		store:     newMemoryJobStore(defaultJobTTL),
+		workers:   defaultJobWorkers,
+		queueSize: defaultJobQueueSize,
+		cancels:   map[string]context.CancelFunc{},
+		progress:  map[string]*jobProgress{},
+	}
+}
+
+func (m *jobManager) start() {
+	m.queue = make(chan jobTask, m.queueSize)
+	for i := 0; i < m.workers; i++ {
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			for task := range m.queue {
+				m.run(task)
+			}
+		}()
+	}
+}
+
+// INFO: cancels all unfinished jobs and waits for workers to exit.
+func (m *jobManager) stop() {
+	m.mu.Lock()
+	if m.stopped {
+		m.mu.Unlock()
+		return
+	}
+	m.stopped = true
+	for _, cancel := range m.cancels {
+		cancel()
+	}
+	close(m.queue)
+	m.mu.Unlock()
+
+	m.wg.Wait()
+}
+
+// INFO: random hex identifier of jobs and stored matrices.
+func newID() (string, error) {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// INFO: detachedContext keeps values of the parent context, e.g. authenticated client, but not its
+// cancellation, so job outlives the submission request.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+// INFO: queues call of the end-point named by op with the uploaded body and the rest of query parameters.
+func (m *jobManager) submit(parent context.Context, op string, query url.Values, contentType string, body []byte) (Job, error) {
+	ctx, cancel := context.WithCancel(context.WithValue(detachedContext{parent}, jobContextKey{}, true))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/"+op+"?"+query.Encode(), bytes.NewReader(body))
+	if err != nil {
+		cancel()
+		return Job{}, errJobOperation
+	}
+	req.Header.Set("Content-Type", contentType)
+	_, pattern := m.mux.Handler(req)
+	if op == "" || pattern == "" || pattern == "/" || strings.HasPrefix(pattern, jobs) {
+		cancel()
+		return Job{}, errJobOperation
+	}
+
+	id, err := newID()
+	if err != nil {
+		cancel()
+		return Job{}, err
+	}
+	job := Job{ID: id, Operation: op, Status: jobQueued, CreatedAt: time.Now(), Owner: jobOwner(parent)}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stopped {
+		cancel()
+		return Job{}, errJobsStopped
+	}
+	err = m.store.Save(job)
+	if err != nil {
+		cancel()
+		return Job{}, err
+	}
+	select {
+	case m.queue <- jobTask{id: id, req: req}:
+		m.cancels[id] = cancel
+		return job, nil
+	default:
+		cancel()
+		_ = m.store.Delete(id)
+		return Job{}, errJobQueueFull
+	}
+}
+
+func (m *jobManager) run(task jobTask) {
+	ctx := task.req.Context()
+	m.mu.Lock()
+	job, err := m.store.Load(task.id)
+	if err != nil || ctx.Err() != nil {
+		m.mu.Unlock()
+		return
+	}
+	started := time.Now()
+	job.Status, job.StartedAt = jobRunning, &started
+	err = m.store.Save(job)
+	progress := &jobProgress{}
+	m.progress[task.id] = progress
+	m.mu.Unlock()
+	if err != nil {
+		return
+	}
+
+	resp := newBufferedResponse()
+	serveJob(m.mux, resp, task.req.WithContext(context.WithValue(ctx, jobProgressKey{}, progress)))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.progress, task.id)
+	if ctx.Err() != nil {
+		return
+	}
+	m.cancels[task.id]()
+	delete(m.cancels, task.id)
+	job, err = m.store.Load(task.id)
+	if err != nil {
+		return
+	}
+
+	finished := time.Now()
+	job.FinishedAt = &finished
+	job.Result = &JobResult{StatusCode: resp.status, ContentType: resp.header.Get("Content-Type"), Body: resp.body.Bytes()}
+	job.Status, job.Progress = jobDone, 1
+	if resp.status >= http.StatusBadRequest {
+		job.Status = jobFailed
+		job.Error = strings.TrimSpace(resp.body.String())
+	}
+	_ = m.store.Save(job)
+}
+
+// INFO: panic in end-point must not kill the worker, it is reported as internal error of the job.
+func serveJob(handler http.Handler, resp *bufferedResponse, req *http.Request) {
+	defer func() {
+		if p := recover(); p != nil {
+			resp.status = http.StatusInternalServerError
+			resp.body.Reset()
+			_, _ = fmt.Fprintf(&resp.body, "job panicked: %v\n", p)
+		}
+	}()
+	handler.ServeHTTP(resp, req)
+	if resp.status == 0 {
+		resp.status = http.StatusOK
+	}
+}
+
+// INFO: owner of jobs submitted with the request, empty when authentication is disabled.
+func jobOwner(ctx context.Context) string {
+	p, _ := principalFrom(ctx)
+	return p.name
+}
+
+// INFO: cancels unfinished job and removes job from the store. Returned job has status it had at the moment
+// of cancellation, or jobCanceled if it wasn't finished.
+func (m *jobManager) cancel(id string, owner string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, err := m.loadLocked(id, owner)
+	if err != nil {
+		return Job{}, err
+	}
+	if cancel, ok := m.cancels[id]; ok {
+		cancel()
+		delete(m.cancels, id)
+	}
+	delete(m.progress, id)
+	if !job.finished() {
+		job.Status = jobCanceled
+	}
+	return job, m.store.Delete(id)
+}
+
+// INFO: jobs of other clients are reported as not found, so their ids can't be probed.
+func (m *jobManager) load(id string, owner string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.loadLocked(id, owner)
+}
+
+func (m *jobManager) loadLocked(id string, owner string) (Job, error) {
+	job, err := m.store.Load(id)
+	if err != nil {
+		return Job{}, err
+	}
+	if job.Owner != owner {
+		return Job{}, errJobNotFound
+	}
+	if progress, ok := m.progress[id]; ok && job.Status == jobRunning {
+		job.Progress = progress.ratio()
+	}
+	return job, nil
+}
+
+// INFO: splits "/jobs/{id}" and "/jobs/{id}/result" paths.
+func parseJobPath(p string) (string, bool, error) {
+	parts := strings.Split(strings.TrimPrefix(p, jobsPrefix), "/")
+	switch {
+	case len(parts) == 1 && parts[0] != "":
+		return parts[0], false, nil
+	case len(parts) == 2 && parts[0] != "" && parts[1] == jobResultSuffix:
+		return parts[0], true, nil
+	default:
+		return "", false, errJobPath
+	}
+}
+
+func jobErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errJobNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errJobOperation):
+		return http.StatusBadRequest
+	case errors.Is(err, errJobQueueFull), errors.Is(err, errJobsStopped):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (rout *Router) SubmitJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	op := strings.Trim(strings.TrimSpace(query.Get(opKey)), "/")
+	query.Del(opKey)
+	body, err := io.ReadAll(&limitedReader{r: r.Body, left: rout.maxExpandedSize})
+	if err != nil {
+		rout.log.Error("reading job body failed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if p, ok := principalFrom(r.Context()); ok && !p.allowed(op) {
+		_ = writeJSON(w, http.StatusForbidden, authError{Error: fmt.Sprintf("%s: %q", errForbidden.Error(), op)})
+		return
+	}
+
+	job, err := rout.jobs.submit(r.Context(), op, query, r.Header.Get("Content-Type"), body)
+	if err != nil {
+		rout.log.Error("submitting job failed", zap.String("operation", op), zap.Error(err))
+		if errors.Is(err, errJobQueueFull) {
+			w.Header().Set("Retry-After", "1")
+		}
+		http.Error(w, err.Error(), jobErrorStatus(err))
+		return
+	}
+	rout.log.Info("Job submitted", zap.String("id", job.ID), zap.String("operation", op))
+
+	w.Header().Set("Location", jobsPrefix+job.ID)
+	err = writeJSON(w, http.StatusAccepted, job)
+	if err != nil {
+		rout.log.Error("writing response failed", zap.Error(err))
+	}
+}
+
+func (rout *Router) Job(w http.ResponseWriter, r *http.Request) {
+	id, result, err := parseJobPath(r.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && result:
+		rout.jobResult(w, id, jobOwner(r.Context()))
+	case r.Method == http.MethodGet:
+		rout.jobStatus(w, id, jobOwner(r.Context()))
+	case r.Method == http.MethodDelete && !result:
+		rout.cancelJob(w, id, jobOwner(r.Context()))
+	default:
+		allow := http.MethodGet + ", " + http.MethodDelete
+		if result {
+			allow = http.MethodGet
+		}
+		w.Header().Set("Allow", allow)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+func (rout *Router) jobStatus(w http.ResponseWriter, id string, owner string) {
+	job, err := rout.jobs.load(id, owner)
+	if err != nil {
+		http.Error(w, err.Error(), jobErrorStatus(err))
+		return
+	}
+
+	err = writeJSON(w, http.StatusOK, job)
+	if err != nil {
+		rout.log.Error("writing response failed", zap.Error(err))
+	}
+}
+
+// INFO: writes end-point response of finished job as is, including its status code.
+func (rout *Router) jobResult(w http.ResponseWriter, id string, owner string) {
+	job, err := rout.jobs.load(id, owner)
+	if err != nil {
+		http.Error(w, err.Error(), jobErrorStatus(err))
+		return
+	}
+	if !job.finished() {
+		http.Error(w, fmt.Sprintf("%s: %s", errJobNotFinished.Error(), job.Status), http.StatusConflict)
+		return
+	}
+
+	if job.Result.ContentType != "" {
+		w.Header().Set("Content-Type", job.Result.ContentType)
+	}
+	w.WriteHeader(job.Result.StatusCode)
+	_, err = io.Copy(w, bytes.NewReader(job.Result.Body))
+	if err != nil {
+		rout.log.Error("writing response failed", zap.Error(err))
+	}
+}
+
+func (rout *Router) cancelJob(w http.ResponseWriter, id string, owner string) {
+	job, err := rout.jobs.cancel(id, owner)
+	if err != nil {
+		http.Error(w, err.Error(), jobErrorStatus(err))
+		return
+	}
+	rout.log.Info("Job canceled", zap.String("id", id))
+
+	err = writeJSON(w, http.StatusOK, job)
+	if err != nil {
+		rout.log.Error("writing response failed", zap.Error(err))
+	}
+}
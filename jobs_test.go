@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func Test_memoryJobStore(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	finished := now.Add(-2 * time.Hour)
+	store := newMemoryJobStore(time.Hour)
+	store.now = func() time.Time { return now }
+
+	assert.NoError(t, store.Save(Job{ID: "old", Status: jobDone, FinishedAt: &finished}))
+	assert.NoError(t, store.Save(Job{ID: "queued", Status: jobQueued}))
+	// INFO: store was swept on the first save only, expired job is just hidden until the next sweep.
+	assert.Len(t, store.jobs, 2)
+
+	tt := []struct {
+		name           string
+		providedID     string
+		expectedResult Job
+		expectedErr    error
+	}{
+		{
+			name:        "fail: unknown job",
+			providedID:  "unknown",
+			expectedErr: errJobNotFound,
+		},
+		{
+			name:        "fail: finished job expired",
+			providedID:  "old",
+			expectedErr: errJobNotFound,
+		},
+		{
+			name:           "success: unfinished job never expires",
+			providedID:     "queued",
+			expectedResult: Job{ID: "queued", Status: jobQueued},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := store.Load(tc.providedID)
+			assert.Equal(t, tc.expectedResult, res)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+
+	now = now.Add(jobSweepInterval)
+	assert.NoError(t, store.Save(Job{ID: "new", Status: jobQueued}))
+	assert.NotContains(t, store.jobs, "old")
+
+	assert.NoError(t, store.Delete("queued"))
+	assert.ErrorIs(t, store.Delete("queued"), errJobNotFound)
+}
+
+func Test_parseJobPath(t *testing.T) {
+	tt := []struct {
+		name           string
+		providedPath   string
+		expectedID     string
+		expectedResult bool
+		expectedErr    error
+	}{
+		{
+			name:         "fail: no id",
+			providedPath: "/jobs/",
+			expectedErr:  errJobPath,
+		},
+		{
+			name:         "fail: unknown suffix",
+			providedPath: "/jobs/42/output",
+			expectedErr:  errJobPath,
+		},
+		{
+			name:         "success: job status",
+			providedPath: "/jobs/42",
+			expectedID:   "42",
+		},
+		{
+			name:           "success: job result",
+			providedPath:   "/jobs/42/result",
+			expectedID:     "42",
+			expectedResult: true,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			id, result, err := parseJobPath(tc.providedPath)
+			assert.Equal(t, tc.expectedID, id)
+			assert.Equal(t, tc.expectedResult, result)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func Test_jobManager(t *testing.T) {
+	started := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/block", func(w http.ResponseWriter, r *http.Request) {
+		progress := progressFrom(r.Context())
+		progress.add(4)
+		progress.finish()
+		started <- struct{}{}
+		<-r.Context().Done()
+	})
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	m := newJobManager(mux)
+	m.workers, m.queueSize = 1, 1
+	m.start()
+	defer m.stop()
+
+	_, err := m.submit(context.Background(), "unknown", url.Values{}, "", nil)
+	assert.ErrorIs(t, err, errJobOperation)
+
+	owner := context.WithValue(context.Background(), principalContextKey{}, newPrincipal("owner", nil))
+	running, err := m.submit(owner, "block", url.Values{}, "", nil)
+	assert.NoError(t, err)
+	<-started
+	queued, err := m.submit(context.Background(), "panic", url.Values{}, "", nil)
+	assert.NoError(t, err)
+	_, err = m.submit(context.Background(), "block", url.Values{}, "", nil)
+	assert.ErrorIs(t, err, errJobQueueFull)
+
+	job, err := m.load(running.ID, "owner")
+	assert.NoError(t, err)
+	assert.Equal(t, jobRunning, job.Status)
+	assert.Equal(t, 0.25, job.Progress)
+
+	_, err = m.load(running.ID, "other")
+	assert.ErrorIs(t, err, errJobNotFound)
+	_, err = m.cancel(running.ID, "")
+	assert.ErrorIs(t, err, errJobNotFound)
+
+	job, err = m.cancel(running.ID, "owner")
+	assert.NoError(t, err)
+	assert.Equal(t, jobCanceled, job.Status)
+	_, err = m.load(running.ID, "owner")
+	assert.ErrorIs(t, err, errJobNotFound)
+
+	job = waitJob(t, m, queued.ID)
+	assert.Equal(t, jobFailed, job.Status)
+	assert.Equal(t, "job panicked: boom", job.Error)
+	assert.Equal(t, http.StatusInternalServerError, job.Result.StatusCode)
+}
+
+// INFO: polls job until it is finished, the store is read directly, so jobs of any owner can be awaited.
+func waitJob(t *testing.T, m *jobManager, id string) Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := m.store.Load(id)
+		assert.NoError(t, err)
+		if job.finished() {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s is not finished in time", id)
+	return Job{}
+}
+
+func TestRouter_Jobs(t *testing.T) {
+	logger, err := zap.NewProduction()
+	assert.NoError(t, err)
+	router, err := NewRouter(logger, WithJobWorkers(2, 4))
+	assert.NoError(t, err)
+	router.InitRoutes()
+	defer router.Close()
+
+	submitReq, err := createFilesReq(testURL+jobs+"?op=sum&axis=rows", formFile{key: fileKey, path: validPath})
+	assert.NoError(t, err)
+	submitW := httptest.NewRecorder()
+	router.ServeHTTP(submitW, submitReq)
+	assert.Equal(t, http.StatusAccepted, submitW.Result().StatusCode)
+
+	var submitted Job
+	assert.NoError(t, json.NewDecoder(submitW.Body).Decode(&submitted))
+	assert.Equal(t, jobsPrefix+submitted.ID, submitW.Header().Get("Location"))
+	assert.Equal(t, "sum", submitted.Operation)
+	waitJob(t, router.jobs, submitted.ID)
+
+	failedReq, err := createFilesReq(testURL+jobs+"?op=invert", formFile{key: fileKey, path: notSquarePath})
+	assert.NoError(t, err)
+	failedW := httptest.NewRecorder()
+	router.ServeHTTP(failedW, failedReq)
+	var failed Job
+	assert.NoError(t, json.NewDecoder(failedW.Body).Decode(&failed))
+	waitJob(t, router.jobs, failed.ID)
+
+	unknownOpReq, err := createFilesReq(testURL+jobs+"?op=jobs", formFile{key: fileKey, path: validPath})
+	assert.NoError(t, err)
+
+	tt := []struct {
+		name         string
+		providedReq  *http.Request
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "fail: job of jobs end-point - BadRequest",
+			providedReq:  unknownOpReq,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: errJobOperation.Error() + "\n",
+		},
+		{
+			name:         "fail: wrong method - MethodNotAllowed",
+			providedReq:  httptest.NewRequest(http.MethodGet, testURL+jobs, nil),
+			expectedCode: http.StatusMethodNotAllowed,
+			expectedBody: http.StatusText(http.StatusMethodNotAllowed) + "\n",
+		},
+		{
+			name:         "fail: unknown job - NotFound",
+			providedReq:  httptest.NewRequest(http.MethodGet, testURL+jobsPrefix+"unknown", nil),
+			expectedCode: http.StatusNotFound,
+			expectedBody: errJobNotFound.Error() + "\n",
+		},
+		{
+			name:         "fail: result of failed job keeps its status - BadRequest",
+			providedReq:  httptest.NewRequest(http.MethodGet, testURL+jobsPrefix+failed.ID+"/result", nil),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: errMatrixNotSquare.Error() + "\n",
+		},
+		{
+			name:         "success: job result",
+			providedReq:  httptest.NewRequest(http.MethodGet, testURL+jobsPrefix+submitted.ID+"/result", nil),
+			expectedCode: http.StatusOK,
+			expectedBody: "6\n15\n24\n",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, tc.providedReq)
+
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+
+	deleteW := httptest.NewRecorder()
+	router.ServeHTTP(deleteW, httptest.NewRequest(http.MethodDelete, testURL+jobsPrefix+submitted.ID, nil))
+	assert.Equal(t, http.StatusOK, deleteW.Result().StatusCode)
+	var deleted Job
+	assert.NoError(t, json.NewDecoder(deleteW.Body).Decode(&deleted))
+	assert.Equal(t, jobDone, deleted.Status)
+
+	statusW := httptest.NewRecorder()
+	router.ServeHTTP(statusW, httptest.NewRequest(http.MethodGet, testURL+jobsPrefix+submitted.ID, nil))
+	assert.Equal(t, http.StatusNotFound, statusW.Result().StatusCode)
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// INFO: idle buckets are removed not more often than this, full bucket is the same as missing one.
+	bucketSweepInterval = time.Minute
+)
+
+var (
+	errServerBusy  = errors.New("server is busy, try again later")
+	errRateLimited = errors.New("rate limit exceeded, try again later")
+)
+
+// INFO: retryAfter formats delay for Retry-After header in whole seconds, at least one.
+func retryAfter(d time.Duration) string {
+	return strconv.Itoa(int(math.Max(1, math.Ceil(d.Seconds()))))
+}
+
+// INFO: concurrencyLimiter bounds number of requests computed at the same time. Requests above the limit wait in
+// a queue for timeout, requests above the queue size are rejected at once.
+type concurrencyLimiter struct {
+	slots     chan struct{}
+	queued    int64
+	maxQueued int64
+	timeout   time.Duration
+}
+
+func newConcurrencyLimiter(maxActive int, maxQueued int, timeout time.Duration) *concurrencyLimiter {
+	return &concurrencyLimiter{slots: make(chan struct{}, maxActive), maxQueued: int64(maxQueued), timeout: timeout}
+}
+
+// INFO: waits for a free slot, returns false when the request should be rejected or the client is gone. Jobs
+// wait without timeout, they have already been accepted.
+func (l *concurrencyLimiter) acquire(r *http.Request) bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	if isJobRequest(r) {
+		select {
+		case l.slots <- struct{}{}:
+			return true
+		case <-r.Context().Done():
+			return false
+		}
+	}
+
+	defer atomic.AddInt64(&l.queued, -1)
+	if atomic.AddInt64(&l.queued, 1) > l.maxQueued {
+		return false
+	}
+	timer := time.NewTimer(l.timeout)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+func (l *concurrencyLimiter) queuedCount() int64 {
+	return atomic.LoadInt64(&l.queued)
+}
+
+func (l *concurrencyLimiter) release() {
+	<-l.slots
+}
+
+func (l *concurrencyLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !l.acquire(r) {
+			w.Header().Set("Retry-After", retryAfter(l.timeout))
+			http.Error(w, errServerBusy.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		defer l.release()
+		next.ServeHTTP(w, r)
+	})
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// INFO: rateLimiter keeps token bucket for every client, the bucket holds up to burst tokens and is refilled
+// with rate tokens per second. Every request takes one token.
+type rateLimiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     float64
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{rate: rate, burst: float64(burst), buckets: map[string]*tokenBucket{}, now: time.Now}
+}
+
+// INFO: takes token of the client, returns time to wait for the next one when the bucket is empty.
+func (l *rateLimiter) allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.refill(key)
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, l.wait(b)
+}
+
+// INFO: reports empty bucket of the client without taking a token.
+func (l *rateLimiter) exhausted(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.refill(key)
+	if b.tokens >= 1 {
+		return false, 0
+	}
+	return true, l.wait(b)
+}
+
+// INFO: returns bucket of the client with tokens added since the last request.
+func (l *rateLimiter) refill(key string) *tokenBucket {
+	now := l.now()
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	return b
+}
+
+// INFO: time until the bucket gets the next token.
+func (l *rateLimiter) wait(b *tokenBucket) time.Duration {
+	return time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < bucketSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	full := time.Duration(l.burst / l.rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= full {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// INFO: identifies client by authenticated principal and by IP address when authentication is disabled. Raw
+// API key header isn't used, otherwise client could bypass the limit with random keys.
+func clientKey(r *http.Request) string {
+	if p, ok := principalFrom(r.Context()); ok {
+		return "principal:" + p.name
+	}
+	return ipKey(r)
+}
+
+func ipKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+func rejectRateLimited(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", retryAfter(wait))
+	http.Error(w, errRateLimited.Error(), http.StatusTooManyRequests)
+}
+
+func (l *rateLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isJobRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if ok, wait := l.allow(clientKey(r)); !ok {
+			rejectRateLimited(w, wait)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_retryAfter(t *testing.T) {
+	assert.Equal(t, "1", retryAfter(0))
+	assert.Equal(t, "1", retryAfter(300*time.Millisecond))
+	assert.Equal(t, "3", retryAfter(2100*time.Millisecond))
+}
+
+func Test_clientKey(t *testing.T) {
+	tt := []struct {
+		name           string
+		providedAddr   string
+		providedKey    string
+		providedName   string
+		expectedResult string
+	}{
+		{
+			name:           "success: authenticated client",
+			providedAddr:   "10.0.0.1:1234",
+			providedName:   "dashboard",
+			expectedResult: "principal:dashboard",
+		},
+		{
+			name:           "success: unverified api key is ignored",
+			providedAddr:   "10.0.0.1:1234",
+			providedKey:    "random",
+			expectedResult: "ip:10.0.0.1",
+		},
+		{
+			name:           "success: ip without port",
+			providedAddr:   "10.0.0.1:1234",
+			expectedResult: "ip:10.0.0.1",
+		},
+		{
+			name:           "success: ipv6",
+			providedAddr:   "[::1]:1234",
+			expectedResult: "ip:::1",
+		},
+		{
+			name:           "success: address without port",
+			providedAddr:   "10.0.0.1",
+			expectedResult: "ip:10.0.0.1",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, testURL, nil)
+			req.RemoteAddr = tc.providedAddr
+			if tc.providedKey != "" {
+				req.Header.Set(apiKeyHeader, tc.providedKey)
+			}
+			if tc.providedName != "" {
+				req = req.WithContext(context.WithValue(req.Context(), principalContextKey{}, newPrincipal(tc.providedName, nil)))
+			}
+			assert.Equal(t, tc.expectedResult, clientKey(req))
+		})
+	}
+}
+
+func Test_rateLimiter(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newRateLimiter(2, 2)
+	l.now = func() time.Time { return now }
+
+	tt := []struct {
+		name          string
+		providedKey   string
+		providedDelay time.Duration
+		expectedOK    bool
+		expectedWait  time.Duration
+	}{
+		{name: "success: first token of burst", providedKey: "a", expectedOK: true},
+		{name: "success: second token of burst", providedKey: "a", expectedOK: true},
+		{name: "fail: bucket is empty", providedKey: "a", expectedOK: false, expectedWait: 500 * time.Millisecond},
+		{name: "success: other client", providedKey: "b", expectedOK: true},
+		{name: "fail: bucket is partially refilled", providedKey: "a", providedDelay: 250 * time.Millisecond,
+			expectedOK: false, expectedWait: 250 * time.Millisecond},
+		{name: "success: bucket is refilled", providedKey: "a", providedDelay: 250 * time.Millisecond, expectedOK: true},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			now = now.Add(tc.providedDelay)
+			ok, wait := l.allow(tc.providedKey)
+			assert.Equal(t, tc.expectedOK, ok)
+			assert.Equal(t, tc.expectedWait, wait)
+		})
+	}
+
+	now = now.Add(bucketSweepInterval)
+	_, _ = l.allow("c")
+	assert.Len(t, l.buckets, 1)
+}
+
+func Test_concurrencyLimiter(t *testing.T) {
+	l := newConcurrencyLimiter(1, 1, time.Second)
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := l.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	}))
+	serve := func() <-chan *httptest.ResponseRecorder {
+		done := make(chan *httptest.ResponseRecorder, 1)
+		go func() {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, testURL, nil))
+			done <- w
+		}()
+		return done
+	}
+
+	first := serve()
+	<-started
+	second := serve()
+	assert.Eventually(t, func() bool {
+		return l.queuedCount() == 1
+	}, time.Second, time.Millisecond)
+
+	rejected := <-serve()
+	assert.Equal(t, http.StatusServiceUnavailable, rejected.Result().StatusCode)
+	assert.Equal(t, "1", rejected.Header().Get("Retry-After"))
+	assert.Equal(t, errServerBusy.Error()+"\n", rejected.Body.String())
+
+	release <- struct{}{}
+	assert.Equal(t, http.StatusOK, (<-first).Result().StatusCode)
+	<-started
+	release <- struct{}{}
+	assert.Equal(t, http.StatusOK, (<-second).Result().StatusCode)
+
+	timeoutLimiter := newConcurrencyLimiter(1, 1, 10*time.Millisecond)
+	timeoutLimiter.slots <- struct{}{}
+	w := httptest.NewRecorder()
+	timeoutLimiter.middleware(http.NotFoundHandler()).ServeHTTP(w, httptest.NewRequest(http.MethodPost, testURL, nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Result().StatusCode)
+}
+
+func TestRouter_rateLimit(t *testing.T) {
+	logger, err := zap.NewProduction()
+	assert.NoError(t, err)
+	router, err := NewRouter(logger, WithRateLimit(100, 100), WithRateLimit(0.5, 1, sum, multiply))
+	assert.NoError(t, err)
+	router.InitRoutes()
+	defer router.Close()
+
+	tt := []struct {
+		name          string
+		providedPath  string
+		expectedCode  int
+		expectedRetry string
+	}{
+		{name: "success: first request", providedPath: sum, expectedCode: http.StatusOK},
+		{name: "fail: route limit is shared - TooManyRequests", providedPath: multiply,
+			expectedCode: http.StatusTooManyRequests, expectedRetry: "2"},
+		{name: "success: other routes use default limit", providedPath: invert, expectedCode: http.StatusOK},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := createFilesReq(testURL+tc.providedPath, formFile{key: fileKey, path: validPath})
+			assert.NoError(t, err)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+			assert.Equal(t, tc.expectedRetry, w.Header().Get("Retry-After"))
+		})
+	}
+}
+
+func TestRouter_rateLimitAuthFailures(t *testing.T) {
+	logger, err := zap.NewProduction()
+	assert.NoError(t, err)
+	keys, err := LoadAPIKeys(keysPath)
+	assert.NoError(t, err)
+	router, err := NewRouter(logger, WithAPIKeys(keys...), WithRateLimit(0.5, 1))
+	assert.NoError(t, err)
+	router.InitRoutes()
+	defer router.Close()
+
+	tt := []struct {
+		name          string
+		providedKey   string
+		providedAddr  string
+		expectedCode  int
+		expectedRetry string
+	}{
+		{name: "fail: unknown api key - Unauthorized", providedKey: "unknown", providedAddr: "10.0.0.1:1000",
+			expectedCode: http.StatusUnauthorized},
+		{name: "fail: failed attempts are limited - TooManyRequests", providedKey: "unknown",
+			providedAddr: "10.0.0.1:1001", expectedCode: http.StatusTooManyRequests, expectedRetry: "2"},
+		{name: "fail: address is limited before credentials are checked - TooManyRequests",
+			providedKey: "dashboard-key", providedAddr: "10.0.0.1:1002", expectedCode: http.StatusTooManyRequests,
+			expectedRetry: "2"},
+		{name: "success: other address", providedKey: "dashboard-key", providedAddr: "10.0.0.2:1000",
+			expectedCode: http.StatusOK},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := createFilesReq(testURL+sum, formFile{key: fileKey, path: validPath})
+			assert.NoError(t, err)
+			req.Header.Set(apiKeyHeader, tc.providedKey)
+			req.RemoteAddr = tc.providedAddr
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+			assert.Equal(t, tc.expectedRetry, w.Header().Get("Retry-After"))
+		})
+	}
+}
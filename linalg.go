@@ -0,0 +1,510 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+const (
+	defaultTolerance = 1e-10
+	defaultMaxIter   = 100
+)
+
+var (
+	errNotSymmetric = errors.New("matrix should be symmetric")
+	// INFO: general matrices may have complex eigenvalues, only real spectrum of symmetric ones is computed.
+	errEigenNotSymmetric   = fmt.Errorf("%w: eigen decomposition is supported only for symmetric matrices", errNotSymmetric)
+	errNotPositiveDefinite = errors.New("matrix should be positive definite")
+	errNoConvergence       = errors.New("algorithm didn't converge, increase max_iter or tol")
+)
+
+func newDense(rows, cols int) [][]float64 {
+	res := make([][]float64, rows)
+	for i := range res {
+		res[i] = make([]float64, cols)
+	}
+	return res
+}
+
+func identity(n int) [][]float64 {
+	res := newDense(n, n)
+	for i := range res {
+		res[i][i] = 1
+	}
+	return res
+}
+
+func cloneDense(a [][]float64) [][]float64 {
+	res := make([][]float64, len(a))
+	for i := range a {
+		res[i] = append([]float64(nil), a[i]...)
+	}
+	return res
+}
+
+func transposeDense(ctx context.Context, a [][]float64) ([][]float64, error) {
+	res := make([][]float64, len(a[0]))
+	err := parallelRows(ctx, len(res), len(a)*len(res), func(block indexRange) error {
+		for j := block.start; j < block.end; j++ {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			res[j] = make([]float64, len(a))
+			for i := range a {
+				res[j][i] = a[i][j]
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// INFO: rows of the product are calculated in parallel, every row is summed in the same order as serial
+// version, so results are bit-identical.
+func mulDense(ctx context.Context, a, b [][]float64) ([][]float64, error) {
+	res := newDense(len(a), len(b[0]))
+	err := parallelRows(ctx, len(a), len(a)*len(b)*len(b[0]), func(block indexRange) error {
+		for i := block.start; i < block.end; i++ {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			for k := range b {
+				if a[i][k] == 0 {
+					continue
+				}
+				for j := range b[k] {
+					res[i][j] += a[i][k] * b[k][j]
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func isSymmetric(a [][]float64, tol float64) bool {
+	for i := range a {
+		for j := 0; j < i; j++ {
+			if math.Abs(a[i][j]-a[j][i]) > tol {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// INFO: converts float matrix back to strings. Values smaller than tolerance are written as 0, so
+// numerical noise doesn't look like a result.
+func denseToMatrix(a [][]float64, tol float64) [][]string {
+	res := make([][]string, len(a))
+	for i := range a {
+		res[i] = make([]string, len(a[i]))
+		for j, v := range a[i] {
+			if math.Abs(v) < tol {
+				v = 0
+			}
+			res[i][j] = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+	return res
+}
+
+// INFO: LU decomposition with partial pivoting, P*A = L*U where L is unit lower triangular. perm[i] is
+// the row of A placed at row i, sign is the permutation parity (used for determinant). For singular matrix
+// U has zero on the diagonal.
+func luDecompose(a [][]float64) (perm []int, l [][]float64, u [][]float64, sign int) {
+	n := len(a)
+	u = cloneDense(a)
+	l = identity(n)
+	perm = make([]int, n)
+	for i := range perm {
+		perm[i] = i
+	}
+	sign = 1
+
+	for k := 0; k < n; k++ {
+		pivot := k
+		for i := k + 1; i < n; i++ {
+			if math.Abs(u[i][k]) > math.Abs(u[pivot][k]) {
+				pivot = i
+			}
+		}
+		if pivot != k {
+			u[k], u[pivot] = u[pivot], u[k]
+			perm[k], perm[pivot] = perm[pivot], perm[k]
+			for j := 0; j < k; j++ {
+				l[k][j], l[pivot][j] = l[pivot][j], l[k][j]
+			}
+			sign = -sign
+		}
+		if u[k][k] == 0 {
+			continue
+		}
+
+		for i := k + 1; i < n; i++ {
+			f := u[i][k] / u[k][k]
+			l[i][k] = f
+			for j := k; j < n; j++ {
+				u[i][j] -= f * u[k][j]
+			}
+		}
+	}
+
+	return perm, l, u, sign
+}
+
+func permutationMatrix(perm []int) [][]float64 {
+	res := newDense(len(perm), len(perm))
+	for i, p := range perm {
+		res[i][p] = 1
+	}
+	return res
+}
+
+// INFO: QR decomposition with Householder reflections, A = Q*R where Q is orthogonal (m x m) and R is
+// upper triangular (m x n). Stops between columns once ctx is done.
+func qrDecompose(ctx context.Context, a [][]float64) (q [][]float64, r [][]float64, err error) {
+	m, n := len(a), len(a[0])
+	r = cloneDense(a)
+	q = identity(m)
+
+	for k := 0; k < n && k < m-1; k++ {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		v := make([]float64, m-k)
+		var norm float64
+		for i := k; i < m; i++ {
+			v[i-k] = r[i][k]
+			norm += r[i][k] * r[i][k]
+		}
+		norm = math.Sqrt(norm)
+		if norm == 0 {
+			continue
+		}
+		if v[0] > 0 {
+			norm = -norm
+		}
+		v[0] -= norm
+
+		var vNorm float64
+		for _, x := range v {
+			vNorm += x * x
+		}
+		vNorm = math.Sqrt(vNorm)
+		if vNorm == 0 {
+			continue
+		}
+		for i := range v {
+			v[i] /= vNorm
+		}
+
+		// R = (I - 2vv^T) * R
+		for j := 0; j < n; j++ {
+			var dot float64
+			for i := k; i < m; i++ {
+				dot += v[i-k] * r[i][j]
+			}
+			for i := k; i < m; i++ {
+				r[i][j] -= 2 * v[i-k] * dot
+			}
+		}
+		// Q = Q * (I - 2vv^T)
+		for i := 0; i < m; i++ {
+			var dot float64
+			for j := k; j < m; j++ {
+				dot += q[i][j] * v[j-k]
+			}
+			for j := k; j < m; j++ {
+				q[i][j] -= 2 * dot * v[j-k]
+			}
+		}
+	}
+
+	return q, r, nil
+}
+
+// INFO: Cholesky decomposition A = L*L^T of symmetric positive definite matrix.
+func choleskyDecompose(a [][]float64, tol float64) ([][]float64, error) {
+	if !isSymmetric(a, tol) {
+		return nil, errNotSymmetric
+	}
+
+	n := len(a)
+	l := newDense(n, n)
+	for j := 0; j < n; j++ {
+		s := a[j][j]
+		for k := 0; k < j; k++ {
+			s -= l[j][k] * l[j][k]
+		}
+		if s <= tol {
+			return nil, errNotPositiveDefinite
+		}
+		l[j][j] = math.Sqrt(s)
+
+		for i := j + 1; i < n; i++ {
+			s := a[i][j]
+			for k := 0; k < j; k++ {
+				s -= l[i][k] * l[j][k]
+			}
+			l[i][j] = s / l[j][j]
+		}
+	}
+
+	return l, nil
+}
+
+// INFO: eigenvalues and eigenvectors of symmetric matrix with cyclic Jacobi rotations. Values are sorted in
+// descending order, vectors are columns of the returned matrix in the same order. ctx is checked before every
+// row of a sweep.
+func symmetricEigen(ctx context.Context, a [][]float64, tol float64, maxIter int) ([]float64, [][]float64, error) {
+	if !isSymmetric(a, tol) {
+		return nil, nil, errEigenNotSymmetric
+	}
+
+	n := len(a)
+	d := cloneDense(a)
+	v := identity(n)
+	converged := false
+	for sweep := 0; sweep < maxIter; sweep++ {
+		var off float64
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				off += d[p][q] * d[p][q]
+			}
+		}
+		if math.Sqrt(off) < tol {
+			converged = true
+			break
+		}
+
+		for p := 0; p < n; p++ {
+			if err := ctx.Err(); err != nil {
+				return nil, nil, err
+			}
+			for q := p + 1; q < n; q++ {
+				if d[p][q] == 0 {
+					continue
+				}
+				theta := (d[q][q] - d[p][p]) / (2 * d[p][q])
+				t := 1 / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				if theta < 0 {
+					t = -t
+				}
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+
+				for k := 0; k < n; k++ {
+					dkp, dkq := d[k][p], d[k][q]
+					d[k][p] = c*dkp - s*dkq
+					d[k][q] = s*dkp + c*dkq
+				}
+				for k := 0; k < n; k++ {
+					dpk, dqk := d[p][k], d[q][k]
+					d[p][k] = c*dpk - s*dqk
+					d[q][k] = s*dpk + c*dqk
+				}
+				for k := 0; k < n; k++ {
+					vkp, vkq := v[k][p], v[k][q]
+					v[k][p] = c*vkp - s*vkq
+					v[k][q] = s*vkp + c*vkq
+				}
+			}
+		}
+	}
+	if !converged {
+		return nil, nil, errNoConvergence
+	}
+
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = d[i][i]
+	}
+	order := sortedDesc(values)
+	return reorder(values, order), reorderColumns(v, order), nil
+}
+
+// INFO: singular value decomposition A = U*diag(S)*V^T with one-sided Jacobi (Hestenes) method. For m x n
+// matrix with m >= n U is m x n, V is n x n. Singular values are sorted in descending order. ctx is checked
+// before every column of a sweep.
+func svdDecompose(ctx context.Context, a [][]float64, tol float64, maxIter int) ([][]float64, []float64, [][]float64, error) {
+	m, n := len(a), len(a[0])
+	u := cloneDense(a)
+	v := identity(n)
+	converged := false
+	for sweep := 0; sweep < maxIter && !converged; sweep++ {
+		converged = true
+		for p := 0; p < n; p++ {
+			if err := ctx.Err(); err != nil {
+				return nil, nil, nil, err
+			}
+			for q := p + 1; q < n; q++ {
+				var alpha, beta, gamma float64
+				for i := 0; i < m; i++ {
+					alpha += u[i][p] * u[i][p]
+					beta += u[i][q] * u[i][q]
+					gamma += u[i][p] * u[i][q]
+				}
+				if gamma == 0 || math.Abs(gamma) <= tol*math.Sqrt(alpha*beta) {
+					continue
+				}
+				converged = false
+
+				zeta := (beta - alpha) / (2 * gamma)
+				t := 1 / (math.Abs(zeta) + math.Sqrt(1+zeta*zeta))
+				if zeta < 0 {
+					t = -t
+				}
+				c := 1 / math.Sqrt(1+t*t)
+				s := c * t
+				for i := 0; i < m; i++ {
+					up, uq := u[i][p], u[i][q]
+					u[i][p] = c*up - s*uq
+					u[i][q] = s*up + c*uq
+				}
+				for i := 0; i < n; i++ {
+					vp, vq := v[i][p], v[i][q]
+					v[i][p] = c*vp - s*vq
+					v[i][q] = s*vp + c*vq
+				}
+			}
+		}
+	}
+	if !converged {
+		return nil, nil, nil, errNoConvergence
+	}
+
+	values := make([]float64, n)
+	for j := 0; j < n; j++ {
+		var norm float64
+		for i := 0; i < m; i++ {
+			norm += u[i][j] * u[i][j]
+		}
+		values[j] = math.Sqrt(norm)
+		if values[j] > tol {
+			for i := 0; i < m; i++ {
+				u[i][j] /= values[j]
+			}
+		}
+	}
+	order := sortedDesc(values)
+	return reorderColumns(u, order), reorder(values, order), reorderColumns(v, order), nil
+}
+
+// INFO: returns indexes of values sorted in descending order.
+func sortedDesc(values []float64) []int {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return values[order[i]] > values[order[j]]
+	})
+	return order
+}
+
+func reorder(values []float64, order []int) []float64 {
+	res := make([]float64, len(order))
+	for i, idx := range order {
+		res[i] = values[idx]
+	}
+	return res
+}
+
+func reorderColumns(a [][]float64, order []int) [][]float64 {
+	res := newDense(len(a), len(order))
+	for i := range a {
+		for j, idx := range order {
+			res[i][j] = a[i][idx]
+		}
+	}
+	return res
+}
+
+func sortedKeys(m map[string][][]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// INFO: solves L*U*X = P*B for every column of B using result of luDecompose.
+func luSolve(perm []int, l, u, b [][]float64) [][]float64 {
+	n, k := len(l), len(b[0])
+	x := newDense(n, k)
+	for c := 0; c < k; c++ {
+		// forward substitution L*y = P*b
+		y := make([]float64, n)
+		for i := 0; i < n; i++ {
+			s := b[perm[i]][c]
+			for j := 0; j < i; j++ {
+				s -= l[i][j] * y[j]
+			}
+			y[i] = s
+		}
+		// back substitution U*x = y
+		for i := n - 1; i >= 0; i-- {
+			s := y[i]
+			for j := i + 1; j < n; j++ {
+				s -= u[i][j] * x[j][c]
+			}
+			x[i][c] = s / u[i][i]
+		}
+	}
+	return x
+}
+
+// INFO: solves upper triangular n x n part of R against first n rows of B.
+func backSubstitute(r, b [][]float64) [][]float64 {
+	n, k := len(r[0]), len(b[0])
+	x := newDense(n, k)
+	for c := 0; c < k; c++ {
+		for i := n - 1; i >= 0; i-- {
+			s := b[i][c]
+			for j := i + 1; j < n; j++ {
+				s -= r[i][j] * x[j][c]
+			}
+			x[i][c] = s / r[i][i]
+		}
+	}
+	return x
+}
+
+// INFO: largest absolute value of the matrix, used to make tolerance relative to the data scale.
+func maxAbs(a [][]float64) float64 {
+	var res float64
+	for i := range a {
+		for _, v := range a[i] {
+			res = math.Max(res, math.Abs(v))
+		}
+	}
+	return res
+}
+
+// INFO: Frobenius norm of A*X - B.
+func residualNorm(ctx context.Context, a, x, b [][]float64) (float64, error) {
+	ax, err := mulDense(ctx, a, x)
+	if err != nil {
+		return 0, err
+	}
+	var res float64
+	for i := range ax {
+		for j := range ax[i] {
+			d := ax[i][j] - b[i][j]
+			res += d * d
+		}
+	}
+	return math.Sqrt(res), nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+const testDelta = 1e-9
+
+func assertDenseEqual(t *testing.T, expected, actual [][]float64) {
+	t.Helper()
+	assert.Equal(t, len(expected), len(actual))
+	for i := range expected {
+		assert.InDeltaSlice(t, expected[i], actual[i], testDelta)
+	}
+}
+
+// INFO: mulDense and transposeDense without cancellation for assertions.
+func mustMulDense(t *testing.T, a, b [][]float64) [][]float64 {
+	t.Helper()
+	res, err := mulDense(context.Background(), a, b)
+	assert.NoError(t, err)
+	return res
+}
+
+func mustTransposeDense(t *testing.T, a [][]float64) [][]float64 {
+	t.Helper()
+	res, err := transposeDense(context.Background(), a)
+	assert.NoError(t, err)
+	return res
+}
+
+func diag(values []float64) [][]float64 {
+	res := newDense(len(values), len(values))
+	for i, v := range values {
+		res[i][i] = v
+	}
+	return res
+}
+
+func Test_luDecompose(t *testing.T) {
+	tt := []struct {
+		name         string
+		providedA    [][]float64
+		expectedSign int
+	}{
+		{
+			name:         "success: pivoting required",
+			providedA:    [][]float64{{0, 2, 1}, {1, 1, 0}, {2, 1, 3}},
+			expectedSign: 1,
+		},
+		{
+			name:         "success: singular matrix",
+			providedA:    [][]float64{{1, 2}, {2, 4}},
+			expectedSign: -1,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			perm, l, u, sign := luDecompose(tc.providedA)
+			assert.Equal(t, tc.expectedSign, sign)
+			assertDenseEqual(t, mustMulDense(t, permutationMatrix(perm), tc.providedA), mustMulDense(t, l, u))
+			for i := range u {
+				for j := 0; j < i; j++ {
+					assert.Zero(t, u[i][j])
+				}
+			}
+		})
+	}
+}
+
+func Test_qrDecompose(t *testing.T) {
+	a := [][]float64{{12, -51, 4}, {6, 167, -68}, {-4, 24, -41}}
+
+	q, r, err := qrDecompose(context.Background(), a)
+	assert.NoError(t, err)
+	assertDenseEqual(t, a, mustMulDense(t, q, r))
+	assertDenseEqual(t, identity(3), mustMulDense(t, mustTransposeDense(t, q), q))
+	for i := range r {
+		for j := 0; j < i; j++ {
+			assert.InDelta(t, 0, r[i][j], testDelta)
+		}
+	}
+}
+
+func Test_choleskyDecompose(t *testing.T) {
+	positive := [][]float64{{4, 12, -16}, {12, 37, -43}, {-16, -43, 98}}
+
+	tt := []struct {
+		name           string
+		providedA      [][]float64
+		expectedResult [][]float64
+		expectedErr    error
+	}{
+		{
+			name:           "fail: not symmetric",
+			providedA:      [][]float64{{1, 2}, {3, 4}},
+			expectedResult: nil,
+			expectedErr:    errNotSymmetric,
+		},
+		{
+			name:           "fail: not positive definite",
+			providedA:      [][]float64{{1, 2}, {2, 1}},
+			expectedResult: nil,
+			expectedErr:    errNotPositiveDefinite,
+		},
+		{
+			name:           "success: decomposed",
+			providedA:      positive,
+			expectedResult: [][]float64{{2, 0, 0}, {6, 1, 0}, {-8, 5, 3}},
+			expectedErr:    nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := choleskyDecompose(tc.providedA, defaultTolerance)
+			assert.ErrorIs(t, err, tc.expectedErr)
+			if tc.expectedResult == nil {
+				assert.Nil(t, res)
+				return
+			}
+			assertDenseEqual(t, tc.expectedResult, res)
+		})
+	}
+}
+
+func Test_symmetricEigen(t *testing.T) {
+	a := [][]float64{{2, -1, 0}, {-1, 2, -1}, {0, -1, 2}}
+
+	values, vectors, err := symmetricEigen(context.Background(), a, defaultTolerance, defaultMaxIter)
+	assert.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{2 + math.Sqrt2, 2, 2 - math.Sqrt2}, values, testDelta)
+	assertDenseEqual(t, mustMulDense(t, a, vectors), mustMulDense(t, vectors, diag(values)))
+
+	_, _, err = symmetricEigen(context.Background(), [][]float64{{1, 2}, {3, 4}}, defaultTolerance, defaultMaxIter)
+	assert.ErrorIs(t, err, errEigenNotSymmetric)
+	assert.ErrorIs(t, err, errNotSymmetric)
+}
+
+func Test_svdDecompose(t *testing.T) {
+	a := [][]float64{{3, 2, 2}, {2, 3, -2}, {1, 0, 4}}
+
+	u, s, v, err := svdDecompose(context.Background(), a, defaultTolerance, defaultMaxIter)
+	assert.NoError(t, err)
+	assert.True(t, s[0] >= s[1] && s[1] >= s[2])
+	assertDenseEqual(t, a, mustMulDense(t, mustMulDense(t, u, diag(s)), mustTransposeDense(t, v)))
+	assertDenseEqual(t, identity(3), mustMulDense(t, mustTransposeDense(t, v), v))
+}
+
+func Test_decomposeMatrix_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	a := [][]float64{{2, -1, 0}, {-1, 2, -1}, {0, -1, 2}}
+
+	for _, method := range []string{methodQR, methodEigen, methodSVD} {
+		_, err := decomposeMatrix(ctx, a, method, defaultTolerance, defaultMaxIter)
+		assert.ErrorIs(t, err, context.Canceled, method)
+	}
+}
+
+func Test_denseToMatrix(t *testing.T) {
+	res := denseToMatrix([][]float64{{1e-17, 2.5}, {-3, 0}}, defaultTolerance)
+	assert.Equal(t, [][]string{{"0", "2.5"}, {"-3", "0"}}, res)
+}
@@ -3,8 +3,7 @@ package main
 import (
 	"go.uber.org/zap"
 	"log"
-	"net"
-	"net/http"
+	"os"
 )
 
 const (
@@ -16,12 +15,62 @@ const (
 //		make run
 // Run tests (with test coverage):
 //		make test
+// Discover end-points and their parameters with:
+//		curl "localhost:8080/openapi.json"
 // Send requests with:
 //		curl -F 'file=@./data/matrix.csv' "localhost:8080/echo"
+//		curl -F 'file=@./data/matrix.xlsx' "localhost:8080/echo?sheet=Data"
+//		curl -F 'file=@./data/matrix.csv.gz' --compressed "localhost:8080/echo"
 //		curl -F 'file=@./data/matrix.csv' "localhost:8080/invert"
 //		curl -F 'file=@./data/matrix.csv' "localhost:8080/flatten"
+//		curl -F 'file=@./data/matrix.csv' "localhost:8080/flatten?order=column&layout=row&sep=%3B"
 //		curl -F 'file=@./data/matrix.csv' "localhost:8080/sum"
 //		curl -F 'file=@./data/matrix.csv' "localhost:8080/multiply"
+//		curl -F 'file=@./data/matrix.csv' "localhost:8080/sum?axis=rows&format=json"
+//		curl -F 'file=@./data/matrix.csv' "localhost:8080/stats?axis=cols&percentiles=10,50,90"
+//		curl -F 'file=@./data/matrix.csv' "localhost:8080/map?op=divide&value=4"
+//		curl -F 'file=@./data/matrix.csv' "localhost:8080/reshape?rows=1&cols=9"
+//		curl -F 'file=@./data/matrix.csv' "localhost:8080/slice?rows=0:2&cols=1:"
+//		curl -F 'file=@./data/matrix.csv' "localhost:8080/select?rows=2,0&cols=1"
+//		curl -F 'file=@./data/matrix.csv' "localhost:8080/rotate?degrees=90"
+//		curl -F 'file=@./data/matrix.csv' "localhost:8080/flip?direction=horizontal"
+//		curl -F 'file=@./data/matrix.csv' "localhost:8080/antitranspose"
+//		curl -F 'file=@./data/matrix.csv' "localhost:8080/diagonal"
+//		curl -F 'file=@./data/matrix.csv' "localhost:8080/triangle?part=upper&fill=0"
+//		curl -F 'file=@./data/matrix.csv' "localhost:8080/decompose?method=qr"
+//		curl -F 'file=@./data/matrix.csv' "localhost:8080/decompose?method=svd&format=zip" -o svd.zip
+//		curl -F 'file=@./data/system.csv' -F 'rhs=@./data/rhs.csv' "localhost:8080/solve"
+//		curl -F 'file=@./data/matrix.csv' "localhost:8080/power?n=5"
+//		curl -F 'file=@./data/system.csv' "localhost:8080/power?n=-2&mode=float"
+//		curl -F 'file=@./data/sparse.mtx' "localhost:8080/sparse/transpose?output=dense"
+//		curl -F 'file=@./data/sparse.coo' -F 'other=@./data/sparse.mtx' "localhost:8080/sparse/matmul"
+//		curl -F 'file=@./data/sparse.mtx' "localhost:8080/sparse/stats"
+//		curl -F 'file=@./data/matrix.csv' "localhost:8080/batch?ops=sum,multiply,flatten,invert"
+//		curl -F 'file=@./data/matrix.csv' "localhost:8080/pipeline?stages=invert,scale:2,sum:rows"
+//		curl -F 'file=@./data/matrix.csv' -F 'file=@./data/blocks.csv' -F 'file=@./data/matrices.zip' "localhost:8080/multi?op=sum"
+//		curl -F 'file=@./data/matrix.csv' "localhost:8080/jobs?op=decompose&method=svd"
+//		curl "localhost:8080/jobs/<id>"
+//		curl "localhost:8080/jobs/<id>/result"
+//		curl -X DELETE "localhost:8080/jobs/<id>"
+//		curl -F 'file=@./data/matrix.csv' -F 'ttl=1h' "localhost:8080/matrices"
+//		curl "localhost:8080/matrices/<id>?format=json"
+//		curl -X DELETE "localhost:8080/matrices/<id>"
+//		curl -F 'matrix_id=<id>' "localhost:8080/invert"
+//		curl -F 'file=@./data/system.csv' -F 'rhs_id=<id>' "localhost:8080/solve"
+//		curl -F 'file=@./data/matrix.csv' -H 'If-None-Match: "<etag>"' "localhost:8080/sum"
+//		curl "localhost:8080/cache/stats"
+// Limit load with:
+//		MAX_CONCURRENT=4 QUEUE_TIMEOUT=5s RATE_LIMIT=10 ROUTE_RATE_LIMITS="/decompose=1:2" make run
+// Require credentials with:
+//		API_KEYS_FILE=./data/keys.json JWT_SECRET=secret make run
+//		curl -H 'X-API-Key: dashboard-key' -F 'file=@./data/matrix.csv' "localhost:8080/sum"
+//		curl -H 'Authorization: Bearer <jwt>' -F 'file=@./data/matrix.csv' "localhost:8080/invert"
+// Serve HTTPS (certificates are reloaded on change) with optional client certificates:
+//		TLS_CERT_FILE=server.crt TLS_KEY_FILE=server.key TLS_CLIENT_CA_FILE=ca.crt make run
+//		curl --cacert ca.crt --cert client.crt --key client.key -F 'file=@./data/matrix.csv' "https://localhost:8080/sum"
+// Allow browser clients from other origins with:
+//		CORS_ALLOWED_ORIGINS=https://ui.example.com CORS_ALLOW_CREDENTIALS=true make run
+//		curl -X OPTIONS -H 'Origin: https://ui.example.com' -H 'Access-Control-Request-Method: POST' -i "localhost:8080/sum"
 
 func main() {
 	logger, err := zap.NewProduction()
@@ -35,11 +84,34 @@ func main() {
 		}
 	}()
 
-	router := NewRouter(logger)
+	opts, err := optionsFromEnv(os.Getenv)
+	if err != nil {
+		logger.Error("reading configuration failed", zap.Error(err))
+		return
+	}
+
+	serverCfg, err := serverConfigFromEnv(os.Getenv)
+	if err != nil {
+		logger.Error("reading server configuration failed", zap.Error(err))
+		return
+	}
+
+	router, err := NewRouter(logger, opts...)
+	if err != nil {
+		logger.Error("configuring router failed", zap.Error(err))
+		return
+	}
 	router.InitRoutes()
+	defer router.Close()
+
+	srv, err := newServer(serverCfg, router, logger)
+	if err != nil {
+		logger.Error("configuring server failed", zap.Error(err))
+		return
+	}
 
-	logger.Info("Server started", zap.String("port", port))
-	err = http.ListenAndServe(net.JoinHostPort(host, port), router)
+	logger.Info("Server started", zap.String("addr", serverCfg.addr), zap.Bool("tls", serverCfg.tlsEnabled()))
+	err = serve(srv, serverCfg)
 	if err != nil {
 		logger.Error("ListenAndServe failed", zap.Error(err))
 		return
@@ -0,0 +1,419 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	matrices       = "/matrices"
+	matricesPrefix = "/matrices/"
+
+	matrixIDKey = "matrix_id"
+	idKeySuffix = "_id"
+	ttlKey      = "ttl"
+
+	matrixFileExt = ".json"
+
+	defaultMatrixTTL         = 24 * time.Hour
+	defaultMatrixMaxBytes    = 256 << 20
+	defaultMatrixMaxMatrices = 1000
+)
+
+var (
+	errMatrixNotFound = errors.New("matrix not found")
+	errMatrixTooLarge = errors.New("matrix exceeds storage quota")
+	errStorageFull    = errors.New("matrix storage quota exceeded, delete unused matrices or try later")
+	errInvalidTTL     = errors.New("invalid ttl, should be positive duration not longer than storage limit, e.g. \"30m\"")
+	errMatrixPath     = errors.New("invalid matrix path, should be \"/matrices/{id}\"")
+)
+
+// INFO: StoredMatrix is parsed matrix kept on the server, Size is the length of matrix in CSV form and is used
+// for quotas.
+type StoredMatrix struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name,omitempty"`
+	Rows      int        `json:"rows"`
+	Cols      int        `json:"cols"`
+	Size      int64      `json:"size"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	Data      [][]string `json:"data,omitempty"`
+}
+
+func (m StoredMatrix) expired(now time.Time) bool {
+	return !now.Before(m.ExpiresAt)
+}
+
+// INFO: metadata returns matrix without data, so it can be kept in indexes and written in responses.
+func (m StoredMatrix) metadata() StoredMatrix {
+	m.Data = nil
+	return m
+}
+
+func matrixSize(matrix [][]string) int64 {
+	var size int64
+	for _, row := range matrix {
+		for _, v := range row {
+			size += int64(len(v)) + 1
+		}
+	}
+	return size
+}
+
+func cloneMatrix(matrix [][]string) [][]string {
+	res := make([][]string, len(matrix))
+	for i, row := range matrix {
+		res[i] = append([]string(nil), row...)
+	}
+	return res
+}
+
+// INFO: MatrixQuota limits total size and number of not expired matrices in a store.
+type MatrixQuota struct {
+	MaxBytes    int64
+	MaxMatrices int
+}
+
+func (q MatrixQuota) check(usedBytes int64, count int, m StoredMatrix) error {
+	if m.Size > q.MaxBytes {
+		return errMatrixTooLarge
+	}
+	if usedBytes+m.Size > q.MaxBytes || count+1 > q.MaxMatrices {
+		return errStorageFull
+	}
+	return nil
+}
+
+// INFO: MatrixStore keeps uploaded matrices, implementations must be safe for concurrent use, drop matrices
+// after ExpiresAt and return errMatrixNotFound for unknown or expired ones.
+type MatrixStore interface {
+	Put(m StoredMatrix) error
+	Get(id string) (StoredMatrix, error)
+	Delete(id string) error
+}
+
+type memoryMatrixStore struct {
+	mu       sync.Mutex
+	quota    MatrixQuota
+	matrices map[string]StoredMatrix
+	used     int64
+	now      func() time.Time
+}
+
+func NewMemoryMatrixStore(quota MatrixQuota) MatrixStore {
+	return &memoryMatrixStore{quota: quota, matrices: map[string]StoredMatrix{}, now: time.Now}
+}
+
+func (s *memoryMatrixStore) sweep() {
+	now := s.now()
+	for id, m := range s.matrices {
+		if m.expired(now) {
+			s.used -= m.Size
+			delete(s.matrices, id)
+		}
+	}
+}
+
+func (s *memoryMatrixStore) Put(m StoredMatrix) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep()
+	err := s.quota.check(s.used, len(s.matrices), m)
+	if err != nil {
+		return err
+	}
+	m.Data = cloneMatrix(m.Data)
+	s.matrices[m.ID] = m
+	s.used += m.Size
+	return nil
+}
+
+func (s *memoryMatrixStore) Get(id string) (StoredMatrix, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.matrices[id]
+	if !ok || m.expired(s.now()) {
+		return StoredMatrix{}, errMatrixNotFound
+	}
+	m.Data = cloneMatrix(m.Data)
+	return m, nil
+}
+
+func (s *memoryMatrixStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.matrices[id]
+	if !ok {
+		return errMatrixNotFound
+	}
+	s.used -= m.Size
+	delete(s.matrices, id)
+	return nil
+}
+
+// INFO: diskMatrixStore keeps every matrix as JSON file in dir, metadata of all matrices is indexed in memory
+// on start, so quotas and expiry don't need to read files.
+type diskMatrixStore struct {
+	mu    sync.Mutex
+	dir   string
+	quota MatrixQuota
+	index map[string]StoredMatrix
+	used  int64
+	now   func() time.Time
+}
+
+func NewDiskMatrixStore(dir string, quota MatrixQuota) (MatrixStore, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+	s := &diskMatrixStore{dir: dir, quota: quota, index: map[string]StoredMatrix{}, now: time.Now}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"+matrixFileExt))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		m, err := readMatrixFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading stored matrix %s: %w", file, err)
+		}
+		s.index[m.ID] = m.metadata()
+		s.used += m.Size
+	}
+	s.sweep()
+	return s, nil
+}
+
+func readMatrixFile(file string) (StoredMatrix, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return StoredMatrix{}, err
+	}
+	var m StoredMatrix
+	err = json.Unmarshal(content, &m)
+	return m, err
+}
+
+func (s *diskMatrixStore) path(id string) string {
+	return filepath.Join(s.dir, id+matrixFileExt)
+}
+
+func (s *diskMatrixStore) remove(id string) error {
+	s.used -= s.index[id].Size
+	delete(s.index, id)
+	err := os.Remove(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *diskMatrixStore) sweep() {
+	now := s.now()
+	for id, m := range s.index {
+		if m.expired(now) {
+			_ = s.remove(id)
+		}
+	}
+}
+
+// INFO: matrix is written to temporary file first, so readers never see partially written one.
+func (s *diskMatrixStore) Put(m StoredMatrix) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep()
+	err := s.quota.check(s.used, len(s.index), m)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, "*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path(m.ID))
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	s.index[m.ID] = m.metadata()
+	s.used += m.Size
+	return nil
+}
+
+func (s *diskMatrixStore) Get(id string) (StoredMatrix, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.index[id]
+	if !ok {
+		return StoredMatrix{}, errMatrixNotFound
+	}
+	if m.expired(s.now()) {
+		_ = s.remove(id)
+		return StoredMatrix{}, errMatrixNotFound
+	}
+	return readMatrixFile(s.path(id))
+}
+
+func (s *diskMatrixStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.index[id]; !ok {
+		return errMatrixNotFound
+	}
+	return s.remove(id)
+}
+
+// INFO: name of parameter with id of stored matrix used instead of file uploaded under the key, "matrix_id"
+// for the main file and "<key>_id" for the others, e.g. "rhs_id".
+func matrixIDParam(key string) string {
+	if key == fileKey {
+		return matrixIDKey
+	}
+	return key + idKeySuffix
+}
+
+// INFO: parses optional ttl of stored matrix, it can't be longer than the limit.
+func parseTTL(raw string, limit time.Duration) (time.Duration, error) {
+	if raw == "" {
+		return limit, nil
+	}
+	ttl, err := time.ParseDuration(raw)
+	if err != nil || ttl <= 0 || ttl > limit {
+		return 0, errInvalidTTL
+	}
+	return ttl, nil
+}
+
+func matrixErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errMatrixNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errMatrixTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, errStorageFull):
+		return http.StatusInsufficientStorage
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (rout *Router) StoreMatrix(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	ttl, err := parseTTL(r.FormValue(ttlKey), rout.matrixTTL)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	matrix, err := rout.parseMatrix(r, fileKey)
+	if err != nil {
+		rout.log.Error("extracting data from .csv file failed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id, err := newID()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	now := time.Now()
+	m := StoredMatrix{
+		ID:        id,
+		Rows:      len(matrix),
+		Cols:      len(matrix[0]),
+		Size:      matrixSize(matrix),
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+		Data:      matrix,
+	}
+	if _, header, err := r.FormFile(fileKey); err == nil {
+		m.Name = header.Filename
+	}
+	err = rout.matrices.Put(m)
+	if err != nil {
+		rout.log.Error("storing matrix failed", zap.Error(err))
+		http.Error(w, err.Error(), matrixErrorStatus(err))
+		return
+	}
+	rout.log.Info("Matrix stored", zap.String("id", id), zap.Int64("size", m.Size))
+
+	w.Header().Set("Location", matricesPrefix+id)
+	err = writeJSON(w, http.StatusCreated, m.metadata())
+	if err != nil {
+		rout.log.Error("writing response failed", zap.Error(err))
+	}
+}
+
+func (rout *Router) Matrix(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, matricesPrefix)
+	if id == "" || strings.Contains(id, "/") {
+		http.Error(w, errMatrixPath.Error(), http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		format, err := parseFormat(r.FormValue(formatKey))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		m, err := rout.matrices.Get(id)
+		if err != nil {
+			http.Error(w, err.Error(), matrixErrorStatus(err))
+			return
+		}
+		if format == formatCSV {
+			rout.writeMatrix(w, m.Data)
+			return
+		}
+		err = writeJSON(w, http.StatusOK, m)
+		if err != nil {
+			rout.log.Error("writing response failed", zap.Error(err))
+		}
+	case http.MethodDelete:
+		err := rout.matrices.Delete(id)
+		if err != nil {
+			http.Error(w, err.Error(), matrixErrorStatus(err))
+			return
+		}
+		rout.log.Info("Matrix deleted", zap.String("id", id))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", http.MethodGet+", "+http.MethodDelete)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
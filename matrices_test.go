@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_parseTTL(t *testing.T) {
+	tt := []struct {
+		name           string
+		providedRaw    string
+		expectedResult time.Duration
+		expectedErr    error
+	}{
+		{
+			name:        "fail: not a duration",
+			providedRaw: "10",
+			expectedErr: errInvalidTTL,
+		},
+		{
+			name:        "fail: negative",
+			providedRaw: "-1m",
+			expectedErr: errInvalidTTL,
+		},
+		{
+			name:        "fail: longer than limit",
+			providedRaw: "2h",
+			expectedErr: errInvalidTTL,
+		},
+		{
+			name:           "success: default is the limit",
+			providedRaw:    "",
+			expectedResult: time.Hour,
+		},
+		{
+			name:           "success: shorter ttl",
+			providedRaw:    "30m",
+			expectedResult: 30 * time.Minute,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := parseTTL(tc.providedRaw, time.Hour)
+			assert.Equal(t, tc.expectedResult, res)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func Test_matrixIDParam(t *testing.T) {
+	assert.Equal(t, "matrix_id", matrixIDParam(fileKey))
+	assert.Equal(t, "rhs_id", matrixIDParam(rhsKey))
+}
+
+func TestMatrixStore(t *testing.T) {
+	now := time.Now()
+	quota := MatrixQuota{MaxBytes: 20, MaxMatrices: 2}
+	dir := t.TempDir()
+
+	memory := NewMemoryMatrixStore(quota).(*memoryMatrixStore)
+	memory.now = func() time.Time { return now }
+	disk, err := NewDiskMatrixStore(dir, quota)
+	assert.NoError(t, err)
+	disk.(*diskMatrixStore).now = func() time.Time { return now }
+
+	matrix := [][]string{{"1", "2"}, {"3", "4"}}
+	stored := StoredMatrix{ID: "a", Rows: 2, Cols: 2, Size: matrixSize(matrix), ExpiresAt: now.Add(time.Hour), Data: matrix}
+	expired := StoredMatrix{ID: "old", Rows: 2, Cols: 2, Size: matrixSize(matrix), ExpiresAt: now, Data: matrix}
+
+	for name, store := range map[string]MatrixStore{"memory": memory, "disk": disk} {
+		store := store
+		t.Run(name, func(t *testing.T) {
+			assert.NoError(t, store.Put(expired))
+			_, err := store.Get(expired.ID)
+			assert.ErrorIs(t, err, errMatrixNotFound)
+
+			assert.NoError(t, store.Put(stored))
+			res, err := store.Get(stored.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, stored.Data, res.Data)
+			res.Data[0][0] = "changed"
+
+			res, err = store.Get(stored.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, "1", res.Data[0][0])
+
+			big := stored
+			big.ID, big.Size = "big", quota.MaxBytes+1
+			assert.ErrorIs(t, store.Put(big), errMatrixTooLarge)
+			second := stored
+			second.ID, second.Size = "second", 13
+			assert.ErrorIs(t, store.Put(second), errStorageFull)
+			second.Size = 4
+			assert.NoError(t, store.Put(second))
+			third := second
+			third.ID = "third"
+			assert.ErrorIs(t, store.Put(third), errStorageFull)
+
+			assert.NoError(t, store.Delete(second.ID))
+			assert.ErrorIs(t, store.Delete(second.ID), errMatrixNotFound)
+			assert.NoError(t, store.Put(third))
+		})
+	}
+
+	reopened, err := NewDiskMatrixStore(dir, quota)
+	assert.NoError(t, err)
+	res, err := reopened.Get(stored.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, stored.Data, res.Data)
+	_, err = reopened.Get("second")
+	assert.ErrorIs(t, err, errMatrixNotFound)
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o644))
+	_, err = NewDiskMatrixStore(dir, quota)
+	assert.Error(t, err)
+}
+
+func TestRouter_Matrices(t *testing.T) {
+	router, err := setupRouter()
+	assert.NoError(t, err)
+
+	storeReq, err := createFilesReq(testURL+matrices+"?ttl=1h", formFile{key: fileKey, path: validPath})
+	assert.NoError(t, err)
+	storeW := httptest.NewRecorder()
+	router.ServeHTTP(storeW, storeReq)
+	assert.Equal(t, http.StatusCreated, storeW.Result().StatusCode)
+
+	var stored StoredMatrix
+	assert.NoError(t, json.NewDecoder(storeW.Body).Decode(&stored))
+	assert.Equal(t, matricesPrefix+stored.ID, storeW.Header().Get("Location"))
+	assert.Equal(t, "matrix.csv", stored.Name)
+	assert.Equal(t, 3, stored.Rows)
+	assert.Nil(t, stored.Data)
+
+	invalidTTLReq, err := createFilesReq(testURL+matrices+"?ttl=1000h", formFile{key: fileKey, path: validPath})
+	assert.NoError(t, err)
+	unknownIDReq, err := createFilesReq(testURL+sum+"?matrix_id=unknown", formFile{key: rhsKey, path: validPath})
+	assert.NoError(t, err)
+	solveReq, err := createFilesReq(testURL+solve+"?rhs_id="+stored.ID, formFile{key: fileKey, path: systemPath})
+	assert.NoError(t, err)
+
+	tt := []struct {
+		name         string
+		providedReq  *http.Request
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "fail: ttl longer than limit - BadRequest",
+			providedReq:  invalidTTLReq,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: errInvalidTTL.Error() + "\n",
+		},
+		{
+			name:         "fail: unknown matrix - NotFound",
+			providedReq:  httptest.NewRequest(http.MethodGet, testURL+matricesPrefix+"unknown", nil),
+			expectedCode: http.StatusNotFound,
+			expectedBody: errMatrixNotFound.Error() + "\n",
+		},
+		{
+			name:         "fail: operation with unknown matrix - BadRequest",
+			providedReq:  unknownIDReq,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: errMatrixNotFound.Error() + "\n",
+		},
+		{
+			name:         "success: stored matrix",
+			providedReq:  httptest.NewRequest(http.MethodGet, testURL+matricesPrefix+stored.ID, nil),
+			expectedCode: http.StatusOK,
+			expectedBody: "1,2,3\n4,5,6\n7,8,9\n",
+		},
+		{
+			name:         "success: operation with stored matrix",
+			providedReq:  httptest.NewRequest(http.MethodPost, testURL+invert+"?matrix_id="+stored.ID, nil),
+			expectedCode: http.StatusOK,
+			expectedBody: "1,4,7\n2,5,8\n3,6,9\n",
+		},
+		{
+			name:         "success: sparse operation with stored matrix",
+			providedReq:  httptest.NewRequest(http.MethodPost, testURL+sparseSum+"?matrix_id="+stored.ID, nil),
+			expectedCode: http.StatusOK,
+			expectedBody: "45\n",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, tc.providedReq)
+
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+
+	solveW := httptest.NewRecorder()
+	router.ServeHTTP(solveW, solveReq)
+	assert.Equal(t, http.StatusOK, solveW.Result().StatusCode)
+
+	deleteW := httptest.NewRecorder()
+	router.ServeHTTP(deleteW, httptest.NewRequest(http.MethodDelete, testURL+matricesPrefix+stored.ID, nil))
+	assert.Equal(t, http.StatusNoContent, deleteW.Result().StatusCode)
+
+	getW := httptest.NewRecorder()
+	router.ServeHTTP(getW, httptest.NewRequest(http.MethodGet, testURL+matricesPrefix+stored.ID, nil))
+	assert.Equal(t, http.StatusNotFound, getW.Result().StatusCode)
+}
@@ -1,13 +1,42 @@
 package main
 
 import (
+	"context"
 	"errors"
+	"math"
 	"strconv"
 	"strings"
 )
 
+const (
+	axisAll  = "all"
+	axisRows = "rows"
+	axisCols = "cols"
+
+	formatCSV  = "csv"
+	formatJSON = "json"
+
+	orderRow      = "row"
+	orderColumn   = "column"
+	orderSnake    = "snake"
+	orderDiagonal = "diagonal"
+
+	layoutRow    = "row"
+	layoutColumn = "column"
+
+	defaultSep = ","
+
+	// INFO: side of square tile of blocked transpose, 32x32 strings fit into L1 cache together with the result.
+	transposeTile = 32
+)
+
 var (
-	errNotIntValue = errors.New("only Integer value is allowed")
+	errNotIntValue   = errors.New("only Integer value is allowed")
+	errNotNumber     = errors.New("only numeric value is allowed")
+	errInvalidAxis   = errors.New("invalid axis, should be one of \"all\", \"rows\", \"cols\"")
+	errInvalidFormat = errors.New("invalid output format, should be one of \"csv\", \"json\"")
+	errInvalidOrder  = errors.New("invalid order, should be one of \"row\", \"column\", \"snake\", \"diagonal\"")
+	errInvalidLayout = errors.New("invalid layout, should be one of \"row\", \"column\"")
 )
 
 // INFO: check is matrix square. Compare number of rows and number of notes in each row (columns).
@@ -43,38 +72,179 @@ func convertToPlainString(matrix [][]string) string {
 	return strings.Join(rows, ",") + "\n"
 }
 
-// INFO: inverting matrix by replacing rows with columns.
-func invertMatrix(matrix [][]string) [][]string {
+// INFO: lists elements in provided order: row-major, column-major, snake (row-major with every odd row
+// reversed) or diagonal (anti-diagonals from top-left corner, each one from top to bottom).
+func flattenMatrix(matrix [][]string, order string) ([]string, error) {
+	rowsNumber := len(matrix)
+	columnsNumber := len(matrix[0])
+	res := make([]string, 0, rowsNumber*columnsNumber)
+
+	switch order {
+	case "", orderRow:
+		for _, row := range matrix {
+			res = append(res, row...)
+		}
+	case orderColumn:
+		for j := 0; j < columnsNumber; j++ {
+			for i := 0; i < rowsNumber; i++ {
+				res = append(res, matrix[i][j])
+			}
+		}
+	case orderSnake:
+		for i, row := range matrix {
+			for j := range row {
+				if i%2 == 1 {
+					j = columnsNumber - 1 - j
+				}
+				res = append(res, row[j])
+			}
+		}
+	case orderDiagonal:
+		for d := 0; d < rowsNumber+columnsNumber-1; d++ {
+			for i := 0; i <= d && i < rowsNumber; i++ {
+				if d-i < columnsNumber {
+					res = append(res, matrix[i][d-i])
+				}
+			}
+		}
+	default:
+		return nil, errInvalidOrder
+	}
+
+	return res, nil
+}
+
+// INFO: joins flattened elements into a single row with separator or into a single column (one value per
+// line, separator is ignored).
+func joinFlat(items []string, layout string, sep string) (string, error) {
+	switch layout {
+	case "", layoutRow:
+		return strings.Join(items, sep) + "\n", nil
+	case layoutColumn:
+		return strings.Join(items, "\n") + "\n", nil
+	default:
+		return "", errInvalidLayout
+	}
+}
+
+// INFO: inverting matrix by replacing rows with columns. Result rows share one contiguous backing array and
+// are filled tile by tile, so both source and destination are accessed within a few cache lines. Blocks of
+// result rows are filled in parallel.
+func invertMatrix(ctx context.Context, matrix [][]string) ([][]string, error) {
 	rowsNumber := len(matrix)
 	columnsNumber := len(matrix[0])
+	data := make([]string, rowsNumber*columnsNumber)
 	inverted := make([][]string, columnsNumber)
-	for i := range inverted {
-		inverted[i] = make([]string, rowsNumber)
+	for j := range inverted {
+		inverted[j] = data[j*rowsNumber : (j+1)*rowsNumber : (j+1)*rowsNumber]
 	}
 
-	for i := 0; i < rowsNumber; i++ {
-		for j := 0; j < columnsNumber; j++ {
-			inverted[j][i] = matrix[i][j]
+	err := parallelRows(ctx, columnsNumber, rowsNumber*columnsNumber, func(block indexRange) error {
+		for jj := block.start; jj < block.end; jj += transposeTile {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			jEnd := minInt(jj+transposeTile, block.end)
+			for ii := 0; ii < rowsNumber; ii += transposeTile {
+				iEnd := minInt(ii+transposeTile, rowsNumber)
+				for i := ii; i < iEnd; i++ {
+					row := matrix[i]
+					for j := jj; j < jEnd; j++ {
+						inverted[j][i] = row[j]
+					}
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return inverted, nil
+}
+
+// INFO: transposes square matrix in place by swapping tiles above the diagonal with tiles below it. Rows
+// must not share backing arrays with each other. Every band of tile rows swaps only its own pairs of cells, so
+// bands are swapped in parallel. Bands get shorter towards the bottom, so they are dealt to blocks in turn to
+// keep the work even.
+func transposeInPlace(ctx context.Context, matrix [][]string) error {
+	n := len(matrix)
+	bands := (n + transposeTile - 1) / transposeTile
+	blocks := rowBlocks(ctx, bands, n*n)
+	return parallelBlocks(ctx, blocks, func(b int, _ indexRange) error {
+		for band := b; band < bands; band += len(blocks) {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			ii := band * transposeTile
+			iEnd := minInt(ii+transposeTile, n)
+			for jj := ii; jj < n; jj += transposeTile {
+				jEnd := minInt(jj+transposeTile, n)
+				for i := ii; i < iEnd; i++ {
+					for j := maxInt(jj, i+1); j < jEnd; j++ {
+						matrix[i][j], matrix[j][i] = matrix[j][i], matrix[i][j]
+					}
+				}
+			}
 		}
+		return nil
+	})
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
 	}
+	return b
+}
 
-	return inverted
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
 }
 
-// INFO: converts each element from string to int. Returns errNotIntValue in case of wrong data type.
-func matrixToInt(matrix [][]string) ([][]int, error) {
+// INFO: converts each element from string to int, blocks of rows are converted in parallel. Returns
+// errNotIntValue in case of wrong data type.
+func matrixToInt(ctx context.Context, matrix [][]string) ([][]int, error) {
 	rowsNumber := len(matrix)
 	columnsNumber := len(matrix[0])
-	res := make([][]int, columnsNumber)
-	for i := range res {
-		res[i] = make([]int, rowsNumber)
+	res := make([][]int, rowsNumber)
+
+	err := parallelRows(ctx, rowsNumber, rowsNumber*columnsNumber, func(block indexRange) error {
+		for i := block.start; i < block.end; i++ {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			res[i] = make([]int, columnsNumber)
+			for j := 0; j < columnsNumber; j++ {
+				elem, err := strconv.Atoi(matrix[i][j])
+				if err != nil {
+					return errNotIntValue
+				}
+				res[i][j] = elem
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	for i := 0; i < rowsNumber; i++ {
-		for j := 0; j < columnsNumber; j++ {
-			elem, err := strconv.Atoi(matrix[i][j])
-			if err != nil {
-				return nil, errNotIntValue
+	return res, nil
+}
+
+// INFO: converts each element from string to float. Returns errNotNumber in case of wrong data type.
+func matrixToFloat(matrix [][]string) ([][]float64, error) {
+	res := make([][]float64, len(matrix))
+	for i, row := range matrix {
+		res[i] = make([]float64, len(row))
+		for j := range row {
+			elem, err := strconv.ParseFloat(strings.TrimSpace(row[j]), 64)
+			if err != nil || math.IsNaN(elem) || math.IsInf(elem, 0) {
+				return nil, errNotNumber
 			}
 			res[i][j] = elem
 		}
@@ -83,34 +253,164 @@ func matrixToInt(matrix [][]string) ([][]int, error) {
 	return res, nil
 }
 
-func sumMatrix(matrix [][]string) (int, error) {
-	var sum int
-	intMatrix, err := matrixToInt(matrix)
+// INFO: sums the whole matrix, see foldAxis. Integer addition wraps around on overflow and is associative, so
+// the result is the same for any number of workers.
+func sumMatrix(ctx context.Context, matrix [][]string) (int, error) {
+	res, err := sumAxis(ctx, matrix, axisAll)
+	if err != nil {
+		return 0, err
+	}
+	return res[0], nil
+}
+
+// INFO: multiplies values of the whole matrix, partial products are deterministic for the same reason as in
+// sumMatrix.
+func multiplyMatrix(ctx context.Context, matrix [][]string) (int, error) {
+	res, err := multiplyAxis(ctx, matrix, axisAll)
 	if err != nil {
 		return 0, err
 	}
+	return res[0], nil
+}
 
-	for i := range intMatrix {
-		for j := range intMatrix[i] {
-			sum += intMatrix[i][j]
+// INFO: multiplies each element by factor. Returns errNotIntValue in case of wrong data type.
+func scaleMatrix(matrix [][]string, factor int) ([][]string, error) {
+	scaled := make([][]string, len(matrix))
+	for i, row := range matrix {
+		scaled[i] = make([]string, len(row))
+		for j := range row {
+			elem, err := strconv.Atoi(row[j])
+			if err != nil {
+				return nil, errNotIntValue
+			}
+			scaled[i][j] = strconv.Itoa(elem * factor)
 		}
 	}
 
-	return sum, nil
+	return scaled, nil
+}
+
+// INFO: validates axis value, empty one means the whole matrix.
+func parseAxis(raw string) (string, error) {
+	switch raw {
+	case "", axisAll:
+		return axisAll, nil
+	case axisRows, axisCols:
+		return raw, nil
+	default:
+		return "", errInvalidAxis
+	}
+}
+
+// INFO: validates output format value, empty one means csv.
+func parseFormat(raw string) (string, error) {
+	switch raw {
+	case "", formatCSV:
+		return formatCSV, nil
+	case formatJSON:
+		return raw, nil
+	default:
+		return "", errInvalidFormat
+	}
 }
 
-func multiplyMatrix(matrix [][]string) (int, error) {
-	result := 1
-	intMatrix, err := matrixToInt(matrix)
+// INFO: folds elements with fn along the axis starting from init value. Returns one value per row for
+// axisRows, one value per column for axisCols and single value for axisAll. Blocks of rows are folded in
+// parallel, partial results of blocks are folded with fn in block order, so fn should be associative and
+// commutative, init should be its identity.
+func foldAxis(ctx context.Context, matrix [][]string, axis string, init int, fn func(acc, elem int) int) ([]int, error) {
+	intMatrix, err := matrixToInt(ctx, matrix)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+
+	var size int
+	switch axis {
+	case axisAll:
+		size = 1
+	case axisRows:
+		size = len(intMatrix)
+	case axisCols:
+		size = len(intMatrix[0])
+	default:
+		return nil, errInvalidAxis
+	}
+	filled := func() []int {
+		res := make([]int, size)
+		for i := range res {
+			res[i] = init
+		}
+		return res
+	}
+
+	// INFO: blocks own their rows, so per-row results are written in place, other axes are folded into
+	// partial results of the block.
+	res := filled()
+	blocks := rowBlocks(ctx, len(intMatrix), len(intMatrix)*len(intMatrix[0]))
+	partial := make([][]int, len(blocks))
+	err = parallelBlocks(ctx, blocks, func(b int, block indexRange) error {
+		acc := res
+		if axis != axisRows {
+			acc = filled()
+			partial[b] = acc
+		}
+		for i := block.start; i < block.end; i++ {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			for j, v := range intMatrix[i] {
+				switch axis {
+				case axisAll:
+					acc[0] = fn(acc[0], v)
+				case axisRows:
+					acc[i] = fn(acc[i], v)
+				case axisCols:
+					acc[j] = fn(acc[j], v)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	for i := range intMatrix {
-		for j := range intMatrix[i] {
-			result *= intMatrix[i][j]
+	for _, acc := range partial {
+		for i, v := range acc {
+			res[i] = fn(res[i], v)
 		}
 	}
+	return res, nil
+}
+
+func sumAxis(ctx context.Context, matrix [][]string, axis string) ([]int, error) {
+	return foldAxis(ctx, matrix, axis, 0, func(acc, elem int) int {
+		return acc + elem
+	})
+}
 
-	return result, nil
+func multiplyAxis(ctx context.Context, matrix [][]string, axis string) ([]int, error) {
+	return foldAxis(ctx, matrix, axis, 1, func(acc, elem int) int {
+		return acc * elem
+	})
+}
+
+// INFO: formats aggregation results. In csv format per-row results are written as a column (one value per
+// line) and per-column results as a single row, json format is always an array (or a number for axisAll).
+func formatVector(values []int, axis string, format string) string {
+	items := make([]string, len(values))
+	for i, v := range values {
+		items[i] = strconv.Itoa(v)
+	}
+
+	switch {
+	case axis == axisAll:
+		return strings.Join(items, ",") + "\n"
+	case format == formatJSON:
+		return "[" + strings.Join(items, ",") + "]\n"
+	case axis == axisRows:
+		return strings.Join(items, "\n") + "\n"
+	default:
+		return strings.Join(items, ",") + "\n"
+	}
 }
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -102,12 +104,63 @@ func Test_invertMatrix(t *testing.T) {
 	for _, tc := range tt {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			res := invertMatrix(tc.providedMatrix)
+			res, err := invertMatrix(context.Background(), tc.providedMatrix)
+			assert.NoError(t, err)
 			assert.Equal(t, tc.expectedResult, res)
 		})
 	}
 }
 
+// INFO: reference transpose without tiles.
+func naiveTranspose(matrix [][]string) [][]string {
+	res := make([][]string, len(matrix[0]))
+	for j := range res {
+		res[j] = make([]string, len(matrix))
+		for i := range matrix {
+			res[j][i] = matrix[i][j]
+		}
+	}
+	return res
+}
+
+func Test_invertMatrix_tiles(t *testing.T) {
+	tt := []struct {
+		name         string
+		providedRows int
+		providedCols int
+	}{
+		{name: "success: single element", providedRows: 1, providedCols: 1},
+		{name: "success: single row", providedRows: 1, providedCols: 70},
+		{name: "success: partial tiles", providedRows: 33, providedCols: 95},
+		{name: "success: exact tiles", providedRows: 64, providedCols: 32},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			matrix := randomMatrix(tc.providedRows, tc.providedCols, 1000)
+			res, err := invertMatrix(context.Background(), matrix)
+			assert.NoError(t, err)
+			assert.Equal(t, naiveTranspose(matrix), res)
+			// INFO: rows of the result must not overlap in the shared backing array.
+			res[0] = append(res[0], "tail")
+			assert.Equal(t, naiveTranspose(matrix)[1:], res[1:])
+		})
+	}
+}
+
+func Test_transposeInPlace(t *testing.T) {
+	for _, size := range []int{1, 2, 31, 70} {
+		size := size
+		t.Run(fmt.Sprintf("success: %dx%d", size, size), func(t *testing.T) {
+			matrix := randomMatrix(size, size, 1000)
+			expected := naiveTranspose(matrix)
+			assert.NoError(t, transposeInPlace(context.Background(), matrix))
+			assert.Equal(t, expected, matrix)
+		})
+	}
+}
+
 func Test_matrixToInt(t *testing.T) {
 	validMatrix := [][]string{{"1", "2"}, {"3", "4"}}
 	invalidMatrix := [][]string{{"1", "b"}, {"3", "4"}}
@@ -135,7 +188,7 @@ func Test_matrixToInt(t *testing.T) {
 	for _, tc := range tt {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			res, err := matrixToInt(tc.providedMatrix)
+			res, err := matrixToInt(context.Background(), tc.providedMatrix)
 			assert.Equal(t, tc.expectedResult, res)
 			assert.ErrorIs(t, err, tc.expectedErr)
 		})
@@ -169,7 +222,7 @@ func Test_sumMatrix(t *testing.T) {
 	for _, tc := range tt {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			res, err := sumMatrix(tc.providedMatrix)
+			res, err := sumMatrix(context.Background(), tc.providedMatrix)
 			assert.Equal(t, tc.expectedResult, res)
 			assert.ErrorIs(t, err, tc.expectedErr)
 		})
@@ -203,9 +256,493 @@ func Test_multiplyMatrix(t *testing.T) {
 	for _, tc := range tt {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			res, err := multiplyMatrix(tc.providedMatrix)
+			res, err := multiplyMatrix(context.Background(), tc.providedMatrix)
+			assert.Equal(t, tc.expectedResult, res)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func Test_scaleMatrix(t *testing.T) {
+	validMatrix := [][]string{{"1", "2"}, {"3", "4"}}
+	invalidMatrix := [][]string{{"1", "b"}, {"3", "4"}}
+
+	tt := []struct {
+		name           string
+		providedMatrix [][]string
+		providedFactor int
+		expectedResult [][]string
+		expectedErr    error
+	}{
+		{
+			name:           "fail: matrix with non-int values",
+			providedMatrix: invalidMatrix,
+			providedFactor: 2,
+			expectedResult: nil,
+			expectedErr:    errNotIntValue,
+		},
+		{
+			name:           "success: each element multiplied by factor",
+			providedMatrix: validMatrix,
+			providedFactor: -2,
+			expectedResult: [][]string{{"-2", "-4"}, {"-6", "-8"}},
+			expectedErr:    nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := scaleMatrix(tc.providedMatrix, tc.providedFactor)
+			assert.Equal(t, tc.expectedResult, res)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func Test_parseAxis(t *testing.T) {
+	tt := []struct {
+		name           string
+		providedRaw    string
+		expectedResult string
+		expectedErr    error
+	}{
+		{
+			name:           "fail: unknown axis",
+			providedRaw:    "diagonal",
+			expectedResult: "",
+			expectedErr:    errInvalidAxis,
+		},
+		{
+			name:           "success: default axis",
+			providedRaw:    "",
+			expectedResult: axisAll,
+			expectedErr:    nil,
+		},
+		{
+			name:           "success: columns axis",
+			providedRaw:    "cols",
+			expectedResult: axisCols,
+			expectedErr:    nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := parseAxis(tc.providedRaw)
+			assert.Equal(t, tc.expectedResult, res)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func Test_sumAxis(t *testing.T) {
+	validMatrix := [][]string{{"1", "2"}, {"3", "4"}}
+	invalidMatrix := [][]string{{"1", "b"}, {"3", "4"}}
+
+	tt := []struct {
+		name           string
+		providedMatrix [][]string
+		providedAxis   string
+		expectedResult []int
+		expectedErr    error
+	}{
+		{
+			name:           "fail: matrix with non-int values",
+			providedMatrix: invalidMatrix,
+			providedAxis:   axisRows,
+			expectedResult: nil,
+			expectedErr:    errNotIntValue,
+		},
+		{
+			name:           "success: whole matrix",
+			providedMatrix: validMatrix,
+			providedAxis:   axisAll,
+			expectedResult: []int{10},
+			expectedErr:    nil,
+		},
+		{
+			name:           "success: per row",
+			providedMatrix: validMatrix,
+			providedAxis:   axisRows,
+			expectedResult: []int{3, 7},
+			expectedErr:    nil,
+		},
+		{
+			name:           "success: per column",
+			providedMatrix: validMatrix,
+			providedAxis:   axisCols,
+			expectedResult: []int{4, 6},
+			expectedErr:    nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := sumAxis(context.Background(), tc.providedMatrix, tc.providedAxis)
+			assert.Equal(t, tc.expectedResult, res)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func Test_multiplyAxis(t *testing.T) {
+	validMatrix := [][]string{{"1", "2"}, {"3", "4"}}
+
+	tt := []struct {
+		name           string
+		providedMatrix [][]string
+		providedAxis   string
+		expectedResult []int
+		expectedErr    error
+	}{
+		{
+			name:           "fail: unknown axis",
+			providedMatrix: validMatrix,
+			providedAxis:   "diagonal",
+			expectedResult: nil,
+			expectedErr:    errInvalidAxis,
+		},
+		{
+			name:           "success: per row",
+			providedMatrix: validMatrix,
+			providedAxis:   axisRows,
+			expectedResult: []int{2, 12},
+			expectedErr:    nil,
+		},
+		{
+			name:           "success: per column",
+			providedMatrix: validMatrix,
+			providedAxis:   axisCols,
+			expectedResult: []int{3, 8},
+			expectedErr:    nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := multiplyAxis(context.Background(), tc.providedMatrix, tc.providedAxis)
+			assert.Equal(t, tc.expectedResult, res)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func Test_formatVector(t *testing.T) {
+	tt := []struct {
+		name           string
+		providedValues []int
+		providedAxis   string
+		providedFormat string
+		expectedResult string
+	}{
+		{
+			name:           "success: single value",
+			providedValues: []int{10},
+			providedAxis:   axisAll,
+			providedFormat: formatJSON,
+			expectedResult: "10\n",
+		},
+		{
+			name:           "success: rows as csv column",
+			providedValues: []int{3, 7},
+			providedAxis:   axisRows,
+			providedFormat: formatCSV,
+			expectedResult: "3\n7\n",
+		},
+		{
+			name:           "success: columns as csv row",
+			providedValues: []int{4, 6},
+			providedAxis:   axisCols,
+			providedFormat: formatCSV,
+			expectedResult: "4,6\n",
+		},
+		{
+			name:           "success: json array",
+			providedValues: []int{3, 7},
+			providedAxis:   axisRows,
+			providedFormat: formatJSON,
+			expectedResult: "[3,7]\n",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res := formatVector(tc.providedValues, tc.providedAxis, tc.providedFormat)
+			assert.Equal(t, tc.expectedResult, res)
+		})
+	}
+}
+
+func Test_matrixToFloat(t *testing.T) {
+	validMatrix := [][]string{{"1", "2.5"}, {"-3", "4e1"}}
+	invalidMatrix := [][]string{{"1", "b"}, {"3", "4"}}
+	nanMatrix := [][]string{{"1", "NaN"}, {"3", "4"}}
+
+	tt := []struct {
+		name           string
+		providedMatrix [][]string
+		expectedResult [][]float64
+		expectedErr    error
+	}{
+		{
+			name:           "fail: matrix with non-numeric values",
+			providedMatrix: invalidMatrix,
+			expectedResult: nil,
+			expectedErr:    errNotNumber,
+		},
+		{
+			name:           "fail: matrix with NaN",
+			providedMatrix: nanMatrix,
+			expectedResult: nil,
+			expectedErr:    errNotNumber,
+		},
+		{
+			name:           "success: valid matrix",
+			providedMatrix: validMatrix,
+			expectedResult: [][]float64{{1, 2.5}, {-3, 40}},
+			expectedErr:    nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := matrixToFloat(tc.providedMatrix)
+			assert.Equal(t, tc.expectedResult, res)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func Test_flattenMatrix(t *testing.T) {
+	matrix := [][]string{{"1", "2", "3"}, {"4", "5", "6"}}
+
+	tt := []struct {
+		name           string
+		providedOrder  string
+		expectedResult []string
+		expectedErr    error
+	}{
+		{
+			name:           "fail: unknown order",
+			providedOrder:  "spiral",
+			expectedResult: nil,
+			expectedErr:    errInvalidOrder,
+		},
+		{
+			name:           "success: default row-major order",
+			providedOrder:  "",
+			expectedResult: []string{"1", "2", "3", "4", "5", "6"},
+			expectedErr:    nil,
+		},
+		{
+			name:           "success: column-major order",
+			providedOrder:  orderColumn,
+			expectedResult: []string{"1", "4", "2", "5", "3", "6"},
+			expectedErr:    nil,
+		},
+		{
+			name:           "success: snake order",
+			providedOrder:  orderSnake,
+			expectedResult: []string{"1", "2", "3", "6", "5", "4"},
+			expectedErr:    nil,
+		},
+		{
+			name:           "success: diagonal order",
+			providedOrder:  orderDiagonal,
+			expectedResult: []string{"1", "2", "4", "3", "5", "6"},
+			expectedErr:    nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := flattenMatrix(matrix, tc.providedOrder)
 			assert.Equal(t, tc.expectedResult, res)
 			assert.ErrorIs(t, err, tc.expectedErr)
 		})
 	}
 }
+
+func Test_joinFlat(t *testing.T) {
+	items := []string{"1", "2", "3"}
+
+	tt := []struct {
+		name           string
+		providedLayout string
+		providedSep    string
+		expectedResult string
+		expectedErr    error
+	}{
+		{
+			name:           "fail: unknown layout",
+			providedLayout: "grid",
+			providedSep:    ",",
+			expectedResult: "",
+			expectedErr:    errInvalidLayout,
+		},
+		{
+			name:           "success: single row with custom separator",
+			providedLayout: layoutRow,
+			providedSep:    " ",
+			expectedResult: "1 2 3\n",
+			expectedErr:    nil,
+		},
+		{
+			name:           "success: single column",
+			providedLayout: layoutColumn,
+			providedSep:    ";",
+			expectedResult: "1\n2\n3\n",
+			expectedErr:    nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := joinFlat(items, tc.providedLayout, tc.providedSep)
+			assert.Equal(t, tc.expectedResult, res)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+var benchmarkSizes = []int{16, 256, 1024}
+
+// INFO: runs fn on random square matrices of every benchmark size.
+func benchmarkMatrix(b *testing.B, fn func(matrix [][]string)) {
+	for _, size := range benchmarkSizes {
+		matrix := randomMatrix(size, size, 100)
+		b.Run(fmt.Sprintf("%dx%d", size, size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				fn(matrix)
+			}
+		})
+	}
+}
+
+func BenchmarkIsSquare(b *testing.B) {
+	benchmarkMatrix(b, func(matrix [][]string) {
+		_ = isSquare(matrix)
+	})
+}
+
+func BenchmarkConvertToMatrixString(b *testing.B) {
+	benchmarkMatrix(b, func(matrix [][]string) {
+		_ = convertToMatrixString(matrix)
+	})
+}
+
+func BenchmarkConvertToPlainString(b *testing.B) {
+	benchmarkMatrix(b, func(matrix [][]string) {
+		_ = convertToPlainString(matrix)
+	})
+}
+
+func BenchmarkFlattenMatrix(b *testing.B) {
+	for _, order := range []string{orderRow, orderColumn, orderSnake, orderDiagonal} {
+		order := order
+		b.Run(order, func(b *testing.B) {
+			benchmarkMatrix(b, func(matrix [][]string) {
+				_, _ = flattenMatrix(matrix, order)
+			})
+		})
+	}
+}
+
+func BenchmarkJoinFlat(b *testing.B) {
+	benchmarkMatrix(b, func(matrix [][]string) {
+		items, _ := flattenMatrix(matrix, orderRow)
+		_, _ = joinFlat(items, layoutRow, defaultSep)
+	})
+}
+
+func BenchmarkInvertMatrix(b *testing.B) {
+	b.Run("naive", func(b *testing.B) {
+		benchmarkMatrix(b, func(matrix [][]string) {
+			_ = naiveTranspose(matrix)
+		})
+	})
+	b.Run("blocked", func(b *testing.B) {
+		benchmarkMatrix(b, func(matrix [][]string) {
+			_, _ = invertMatrix(context.Background(), matrix)
+		})
+	})
+}
+
+func BenchmarkTransposeInPlace(b *testing.B) {
+	benchmarkMatrix(b, func(matrix [][]string) {
+		_ = transposeInPlace(context.Background(), matrix)
+	})
+}
+
+func BenchmarkMatrixToInt(b *testing.B) {
+	benchmarkMatrix(b, func(matrix [][]string) {
+		_, _ = matrixToInt(context.Background(), matrix)
+	})
+}
+
+func BenchmarkMatrixToFloat(b *testing.B) {
+	benchmarkMatrix(b, func(matrix [][]string) {
+		_, _ = matrixToFloat(matrix)
+	})
+}
+
+func BenchmarkSumMatrix(b *testing.B) {
+	benchmarkMatrix(b, func(matrix [][]string) {
+		_, _ = sumMatrix(context.Background(), matrix)
+	})
+}
+
+func BenchmarkMultiplyMatrix(b *testing.B) {
+	benchmarkMatrix(b, func(matrix [][]string) {
+		_, _ = multiplyMatrix(context.Background(), matrix)
+	})
+}
+
+func BenchmarkScaleMatrix(b *testing.B) {
+	benchmarkMatrix(b, func(matrix [][]string) {
+		_, _ = scaleMatrix(matrix, 3)
+	})
+}
+
+func BenchmarkSumAxis(b *testing.B) {
+	for _, axis := range []string{axisRows, axisCols} {
+		axis := axis
+		b.Run(axis, func(b *testing.B) {
+			benchmarkMatrix(b, func(matrix [][]string) {
+				_, _ = sumAxis(context.Background(), matrix, axis)
+			})
+		})
+	}
+}
+
+func BenchmarkMultiplyAxis(b *testing.B) {
+	for _, axis := range []string{axisRows, axisCols} {
+		axis := axis
+		b.Run(axis, func(b *testing.B) {
+			benchmarkMatrix(b, func(matrix [][]string) {
+				_, _ = multiplyAxis(context.Background(), matrix, axis)
+			})
+		})
+	}
+}
+
+func BenchmarkFormatVector(b *testing.B) {
+	for _, format := range []string{formatCSV, formatJSON} {
+		format := format
+		b.Run(format, func(b *testing.B) {
+			benchmarkMatrix(b, func(matrix [][]string) {
+				values, _ := sumAxis(context.Background(), matrix, axisRows)
+				_ = formatVector(values, axisRows, format)
+			})
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+)
+
+const (
+	multi = "/multi"
+
+	zipExt = ".zip"
+
+	// INFO: the same limit as http.Request.FormFile uses.
+	multipartMaxMemory = 32 << 20
+)
+
+var (
+	errNoOperation    = errors.New("no operation provided, use \"op\" parameter with one of batch operations")
+	errTooManyEntries = errors.New("too many matrices in one request")
+)
+
+// INFO: upper bound of matrices in one request, counts all files, archive entries and CSV blocks.
+var maxMultiEntries = 1000
+
+// INFO: namedMatrix is a single matrix of multi upload, err is set when this particular matrix couldn't be read.
+type namedMatrix struct {
+	name   string
+	matrix [][]string
+	err    error
+}
+
+type multiResult struct {
+	Result string `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type multiResponse struct {
+	Operation string                 `json:"operation"`
+	Results   map[string]multiResult `json:"results"`
+}
+
+// INFO: reads every matrix from all files uploaded under the key and stored matrices with ids from
+// matrixIDParam parameters. Zip archives are unpacked, CSV and plain text files may contain several matrices
+// separated by blank lines. Errors of separate matrices are kept in entries, only broken request itself is
+// returned as error. Decompressed content of all files and archive entries shares the budget of
+// WithMaxExpandedSize, so many small bombs in one request can't bypass the limit.
+func (rout *Router) collectMatrices(r *http.Request, key string) ([]namedMatrix, error) {
+	err := r.ParseMultipartForm(multipartMaxMemory)
+	if err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return nil, err
+	}
+	var headers []*multipart.FileHeader
+	if r.MultipartForm != nil {
+		headers = r.MultipartForm.File[key]
+	}
+	ids := r.Form[matrixIDParam(key)]
+	if len(headers) == 0 && len(ids) == 0 {
+		return nil, http.ErrMissingFile
+	}
+
+	var res []namedMatrix
+	if len(ids) > maxMultiEntries {
+		return nil, errTooManyEntries
+	}
+	for _, id := range ids {
+		m, err := rout.matrices.Get(id)
+		if err == nil && !isSquare(m.Data) {
+			err = errMatrixNotSquare
+		}
+		if err != nil {
+			res = append(res, namedMatrix{name: id, err: err})
+			continue
+		}
+		res = append(res, namedMatrix{name: id, matrix: m.Data})
+	}
+	left := rout.maxExpandedSize
+	for _, header := range headers {
+		entries, err := readUpload(header, &left, rout.decodeOptions(r))
+		if errors.Is(err, errExpandedTooLarge) || left < 0 {
+			return nil, errExpandedTooLarge
+		}
+		if err != nil {
+			entries = []namedMatrix{{name: header.Filename, err: err}}
+		}
+		res = append(res, entries...)
+		if len(res) > maxMultiEntries {
+			return nil, errTooManyEntries
+		}
+	}
+	return uniqueNames(res), nil
+}
+
+func readUpload(header *multipart.FileHeader, left *int64, opts decodeOptions) ([]namedMatrix, error) {
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if strings.ToLower(path.Ext(header.Filename)) == zipExt {
+		return readZip(file, header.Size, left, opts)
+	}
+	return readEntry(file, header.Filename, header.Header.Get("Content-Type"), left, opts)
+}
+
+// INFO: every file of archive is read as separate upload, directories are skipped. Reading stops as soon as
+// budget of decompressed bytes is exhausted.
+func readZip(file io.ReaderAt, size int64, left *int64, opts decodeOptions) ([]namedMatrix, error) {
+	archive, err := zip.NewReader(file, size)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []namedMatrix
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		entries, err := readZipFile(f, left, opts)
+		if errors.Is(err, errExpandedTooLarge) || *left < 0 {
+			return nil, errExpandedTooLarge
+		}
+		if err != nil {
+			entries = []namedMatrix{{name: f.Name, err: err}}
+		}
+		res = append(res, entries...)
+		if len(res) > maxMultiEntries {
+			return nil, errTooManyEntries
+		}
+	}
+	return res, nil
+}
+
+func readZipFile(f *zip.File, left *int64, opts decodeOptions) ([]namedMatrix, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return readEntry(rc, f.Name, "", left, opts)
+}
+
+// INFO: decodes single file, CSV and plain text are split into blocks by blank lines and every block is named
+// as "<file>#<n>" when there are more than one of them. Read bytes of decompressed content are taken from
+// the budget left.
+func readEntry(r io.Reader, filename string, contentType string, left *int64, opts decodeOptions) ([]namedMatrix, error) {
+	decompressed, filename, err := decompressFile(r, filename, *left)
+	if err != nil {
+		return nil, err
+	}
+	content := &limitedReader{r: decompressed, left: *left}
+	defer func() {
+		*left = content.left
+	}()
+	decode, err := lookupDecoder(filename, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	ext := strings.ToLower(path.Ext(filename))
+	if ext != csvExt && ext != txtExt {
+		return []namedMatrix{decodeEntry(decode, content, filename, opts)}, nil
+	}
+
+	blocks, err := splitBlocks(content)
+	if err != nil {
+		return nil, err
+	}
+	if len(blocks) <= 1 {
+		return []namedMatrix{decodeEntry(decode, bytes.NewReader(bytes.Join(blocks, nil)), filename, opts)}, nil
+	}
+	res := make([]namedMatrix, 0, len(blocks))
+	for i, block := range blocks {
+		res = append(res, decodeEntry(decode, bytes.NewReader(block), fmt.Sprintf("%s#%d", filename, i+1), opts))
+	}
+	return res, nil
+}
+
+func decodeEntry(decode decoder, r io.Reader, name string, opts decodeOptions) namedMatrix {
+	matrix, err := decode(r, opts)
+	switch {
+	case err != nil:
+	case matrix == nil:
+		err = errEmptyFile
+	case !isSquare(matrix):
+		err = errMatrixNotSquare
+	}
+	if err != nil {
+		return namedMatrix{name: name, err: err}
+	}
+	return namedMatrix{name: name, matrix: matrix}
+}
+
+// INFO: splits content into non-empty blocks separated by one or more blank lines.
+func splitBlocks(r io.Reader) ([][]byte, error) {
+	var (
+		res   [][]byte
+		block []byte
+	)
+	err := readLines(r, func(line []byte) error {
+		if len(bytes.TrimSpace(line)) == 0 {
+			if len(block) > 0 {
+				res = append(res, block)
+				block = nil
+			}
+			return nil
+		}
+		block = append(block, line...)
+		block = append(block, '\n')
+		return nil
+	})
+	if len(block) > 0 {
+		res = append(res, block)
+	}
+	return res, err
+}
+
+// INFO: repeated names (e.g. the same file in form and archive) get "(<n>)" suffix, so no result is lost.
+func uniqueNames(entries []namedMatrix) []namedMatrix {
+	seen := make(map[string]int, len(entries))
+	for i := range entries {
+		name := entries[i].name
+		seen[name]++
+		if seen[name] > 1 {
+			entries[i].name = fmt.Sprintf("%s (%d)", name, seen[name])
+		}
+	}
+	return entries
+}
+
+// INFO: runs operation on every matrix independently, broken matrices and failed calculations are reported
+// in their own results.
+func runMulti(ctx context.Context, entries []namedMatrix, name string, op operation) multiResponse {
+	resp := multiResponse{Operation: name, Results: make(map[string]multiResult, len(entries))}
+	for _, entry := range entries {
+		if entry.err != nil {
+			resp.Results[entry.name] = multiResult{Error: entry.err.Error()}
+			continue
+		}
+		out, err := op(ctx, entry.matrix)
+		if err != nil {
+			resp.Results[entry.name] = multiResult{Error: err.Error()}
+			continue
+		}
+		resp.Results[entry.name] = multiResult{Result: out}
+	}
+	return resp
+}
+
+func (rout *Router) Multi(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSpace(r.FormValue(opKey))
+	if name == "" {
+		http.Error(w, errNoOperation.Error(), http.StatusBadRequest)
+		return
+	}
+	op, ok := operations[name]
+	if !ok {
+		http.Error(w, fmt.Sprintf("%s: %q", errUnknownOperation.Error(), name), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := rout.collectMatrices(r, fileKey)
+	if err != nil {
+		rout.log.Error("extracting data from uploaded files failed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := runMulti(r.Context(), entries, name, op)
+	rout.log.Info("Multi command called", zap.String("operation", name), zap.Int("matrices", len(entries)))
+
+	err = writeJSON(w, http.StatusOK, response)
+	if err != nil {
+		rout.log.Error("writing response failed", zap.Error(err))
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const (
+	blocksPath = "./data/blocks.csv"
+	zipPath    = "./data/matrices.zip"
+)
+
+func Test_splitBlocks(t *testing.T) {
+	// INFO: row is wider than 64KB default limit of bufio.Scanner.
+	wideRow := strings.TrimSuffix(strings.Repeat("1,", 40_000), ",")
+
+	tt := []struct {
+		name           string
+		providedData   string
+		expectedResult []string
+	}{
+		{
+			name:           "success: only blank lines",
+			providedData:   "\n  \n\n",
+			expectedResult: nil,
+		},
+		{
+			name:           "success: single block",
+			providedData:   "1,2\n3,4",
+			expectedResult: []string{"1,2\n3,4\n"},
+		},
+		{
+			name:           "success: blocks separated by several blank lines",
+			providedData:   "\n1,2\n3,4\n\n \n5\n",
+			expectedResult: []string{"1,2\n3,4\n", "5\n"},
+		},
+		{
+			name:           "success: wide rows",
+			providedData:   wideRow + "\n\n" + wideRow + "\n" + wideRow,
+			expectedResult: []string{wideRow + "\n", wideRow + "\n" + wideRow + "\n"},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			blocks, err := splitBlocks(strings.NewReader(tc.providedData))
+			assert.NoError(t, err)
+			var res []string
+			for _, block := range blocks {
+				res = append(res, string(block))
+			}
+			assert.Equal(t, tc.expectedResult, res)
+		})
+	}
+}
+
+func Test_uniqueNames(t *testing.T) {
+	res := uniqueNames([]namedMatrix{{name: "a.csv"}, {name: "b.csv"}, {name: "a.csv"}, {name: "a.csv"}})
+	assert.Equal(t, []namedMatrix{{name: "a.csv"}, {name: "b.csv"}, {name: "a.csv (2)"}, {name: "a.csv (3)"}}, res)
+}
+
+func Test_runMulti(t *testing.T) {
+	entries := []namedMatrix{
+		{name: "valid.csv", matrix: [][]string{{"1", "2"}, {"3", "4"}}},
+		{name: "letters.csv", matrix: [][]string{{"1", "b"}, {"3", "4"}}},
+		{name: "broken.csv", err: errMatrixNotSquare},
+	}
+
+	res := runMulti(context.Background(), entries, "sum", operations["sum"])
+	assert.Equal(t, multiResponse{
+		Operation: "sum",
+		Results: map[string]multiResult{
+			"valid.csv":   {Result: "10\n"},
+			"letters.csv": {Error: errNotIntValue.Error()},
+			"broken.csv":  {Error: errMatrixNotSquare.Error()},
+		},
+	}, res)
+}
+
+func TestRouter_Multi(t *testing.T) {
+	router, err := setupRouter()
+	assert.NoError(t, err)
+
+	filesReq, err := createFilesReq(testURL+multi+"?op=sum",
+		formFile{key: fileKey, path: validPath},
+		formFile{key: fileKey, path: blocksPath},
+		formFile{key: fileKey, path: zipPath},
+		formFile{key: fileKey, path: unsupportedPath},
+		formFile{key: fileKey, path: validPath},
+	)
+	assert.NoError(t, err)
+	noOpReq, err := createFilesReq(testURL+multi, formFile{key: fileKey, path: validPath})
+	assert.NoError(t, err)
+	unknownOpReq, err := createFilesReq(testURL+multi+"?op=transpose", formFile{key: fileKey, path: validPath})
+	assert.NoError(t, err)
+	noFilesReq, err := createFilesReq(testURL+multi+"?op=sum", formFile{key: rhsKey, path: validPath})
+	assert.NoError(t, err)
+
+	tt := []struct {
+		name           string
+		providedReq    *http.Request
+		expectedCode   int
+		expectedBody   string
+		expectedResult multiResponse
+	}{
+		{
+			name:         "fail: no operation - BadRequest",
+			providedReq:  noOpReq,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: errNoOperation.Error() + "\n",
+		},
+		{
+			name:         "fail: unknown operation - BadRequest",
+			providedReq:  unknownOpReq,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "unknown operation: \"transpose\"\n",
+		},
+		{
+			name:         "fail: no files - BadRequest",
+			providedReq:  noFilesReq,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: http.ErrMissingFile.Error() + "\n",
+		},
+		{
+			name:         "success: results keyed by file name",
+			providedReq:  filesReq,
+			expectedCode: http.StatusOK,
+			expectedResult: multiResponse{
+				Operation: "sum",
+				Results: map[string]multiResult{
+					"matrix.csv":        {Result: "45\n"},
+					"blocks.csv#1":      {Result: "10\n"},
+					"blocks.csv#2":      {Result: "26\n"},
+					"blocks.csv#3":      {Error: errMatrixNotSquare.Error()},
+					"nested/matrix.csv": {Result: "45\n"},
+					"notSquare.csv":     {Error: errMatrixNotSquare.Error()},
+					"matrix.dat":        {Error: errFileExtension.Error()},
+					"matrix.dat (2)":    {Error: errFileExtension.Error()},
+					"matrix.csv (2)":    {Result: "45\n"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, tc.providedReq)
+
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+			if tc.expectedCode != http.StatusOK {
+				assert.Equal(t, tc.expectedBody, w.Body.String())
+				return
+			}
+			var res multiResponse
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&res))
+			assert.Equal(t, tc.expectedResult, res)
+		})
+	}
+}
+
+func TestRouter_Multi_decompressionBudget(t *testing.T) {
+	router, err := setupRouter(WithMaxExpandedSize(1 << 10))
+	assert.NoError(t, err)
+
+	// INFO: every entry fits into the limit alone, but together they exceed it.
+	archive := new(bytes.Buffer)
+	zw := zip.NewWriter(archive)
+	for _, name := range []string{"a.csv", "b.csv", "c.csv"} {
+		f, err := zw.Create(name)
+		assert.NoError(t, err)
+		_, err = f.Write(bytes.Repeat([]byte(" "), 600))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+
+	body := new(bytes.Buffer)
+	writer := multipartWriterWithFile(t, body, "bombs.zip", archive.Bytes())
+	req := httptest.NewRequest(http.MethodPost, testURL+multi+"?op=sum", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+	assert.Equal(t, errExpandedTooLarge.Error()+"\n", w.Body.String())
+}
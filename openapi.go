@@ -0,0 +1,555 @@
+package main
+
+import (
+	"go.uber.org/zap"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	openAPIPath    = "/openapi.json"
+	openAPIVersion = "3.0.3"
+	apiTitle       = "Matrix service"
+	apiVersion     = "1.0.0"
+
+	contentText      = "text/plain"
+	contentJSON      = "application/json"
+	contentZip       = "application/zip"
+	contentMultipart = "multipart/form-data"
+
+	apiKeyScheme = "apiKey"
+	bearerScheme = "bearerToken"
+)
+
+// INFO: OpenAPI document types, only the parts used by the service are described.
+type openAPIDoc struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+	Security   []map[string][]string                   `json:"security,omitempty"`
+}
+
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*schema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]securityScheme `json:"securitySchemes,omitempty"`
+}
+
+type securityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+}
+
+type openAPIOperation struct {
+	Summary     string                     `json:"summary"`
+	OperationID string                     `json:"operationId"`
+	Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+	Security    *[]map[string][]string     `json:"security,omitempty"`
+}
+
+type openAPIParameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *schema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema,omitempty"`
+}
+
+type openAPIResponse struct {
+	Description string               `json:"description"`
+	Content     map[string]mediaType `json:"content,omitempty"`
+}
+
+type schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	AdditionalProperties *schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+// INFO: paramDoc describes query or form value, kind is OpenAPI type of the value.
+type paramDoc struct {
+	name        string
+	kind        string
+	description string
+	enum        []string
+	required    bool
+}
+
+// INFO: responseDoc describes successful response, body is Go value of JSON response which schema is
+// generated from.
+type responseDoc struct {
+	status      int
+	description string
+	contentType string
+	body        any
+}
+
+// INFO: operationDoc describes one method of a path. Files are matrices uploaded as multipart form, each of
+// them can be replaced with id of a stored matrix in "<file>_id" value. Formats describe accepted files,
+// dense ones by default.
+type operationDoc struct {
+	summary   string
+	files     []string
+	formats   string
+	params    []paramDoc
+	responses []responseDoc
+}
+
+// INFO: pathDoc describes path served by route, prefix routes serve several paths with parameters.
+type pathDoc struct {
+	path    string
+	methods map[string]operationDoc
+}
+
+func queryParam(name string, kind string, description string, enum ...string) paramDoc {
+	return paramDoc{name: name, kind: kind, description: description, enum: enum}
+}
+
+func requiredParam(p paramDoc) paramDoc {
+	p.required = true
+	return p
+}
+
+var (
+	sheetParam  = queryParam(sheetKey, "string", "sheet of .xlsx file, the first one by default")
+	axisParam   = queryParam(axisKey, "string", "axis of aggregation", axisAll, axisRows, axisCols)
+	formatParam = queryParam(formatKey, "string", "output format", formatCSV, formatJSON)
+	tolParam    = queryParam(tolKey, "number", "tolerance of numeric checks")
+	iterParam   = queryParam(maxIterKey, "integer", "limit of iterations of iterative methods")
+	outputParam = queryParam(outputKey, "string", "output of sparse matrix", outputSparse, outputDense)
+	// INFO: shape hints of triplets input are shared by all files of the request.
+	sparseRowsParam = queryParam(rowsKey, "integer", "number of rows of triplets input, the largest row index + 1 by default")
+	sparseColsParam = queryParam(colsKey, "integer", "number of columns of triplets input, the largest column index + 1 by default")
+
+	matrixResponse = responseDoc{status: http.StatusOK, description: "matrix in CSV view", contentType: contentText}
+	scalarResponse = responseDoc{status: http.StatusOK, description: "single value", contentType: contentText}
+	vectorResponse = responseDoc{status: http.StatusOK, description: "value for the whole matrix, each row or each column", contentType: contentText}
+	vectorJSON     = responseDoc{status: http.StatusOK, description: "values of rows or columns with json format", contentType: contentJSON, body: []int{}}
+)
+
+const (
+	denseFormats  = "matrix file: .csv, .tsv, .txt, .mtx, .xlsx"
+	sparseFormats = "sparse matrix file: .mtx, .coo or .csv with row,col,value triplets"
+	compressions  = ", optionally compressed with gzip (.gz) or zstd (.zst)"
+)
+
+// INFO: operationRoute builds docs of a route which computes result of uploaded matrices with POST request.
+func operationRoute(pattern string, summary string, files []string, params []paramDoc, responses ...responseDoc) []pathDoc {
+	return postRoute(pattern, operationDoc{
+		summary: summary, files: files, params: append(params, sheetParam), responses: responses,
+	})
+}
+
+// INFO: sparseRoute builds docs of an operation route of sparse matrices. They are uploaded as coordinate
+// lists, so sheet parameter isn't used, shape of triplets may be set with rows and cols instead.
+func sparseRoute(pattern string, summary string, files []string, params []paramDoc, responses ...responseDoc) []pathDoc {
+	return postRoute(pattern, operationDoc{
+		summary: summary, files: files, formats: sparseFormats,
+		params: append(params, sparseRowsParam, sparseColsParam), responses: responses,
+	})
+}
+
+func postRoute(pattern string, d operationDoc) []pathDoc {
+	return []pathDoc{{path: pattern, methods: map[string]operationDoc{http.MethodPost: d}}}
+}
+
+var mainFile = []string{fileKey}
+
+// INFO: routeDocs describes every route registered in InitRoutes, TestRouter_openAPI fails for undocumented
+// ones.
+var routeDocs = map[string][]pathDoc{
+	echo:   operationRoute(echo, "Returns uploaded matrix", mainFile, nil, matrixResponse),
+	invert: operationRoute(invert, "Transposes square matrix", mainFile, nil, matrixResponse),
+	flatten: operationRoute(flatten, "Flattens matrix into a single line", mainFile, []paramDoc{
+		queryParam(orderKey, "string", "order of traversal", orderRow, orderColumn, orderSnake, orderDiagonal),
+		queryParam(layoutKey, "string", "layout of values", layoutRow, layoutColumn),
+		queryParam(sepKey, "string", "separator of values, comma by default"),
+	}, responseDoc{status: http.StatusOK, description: "flattened values", contentType: contentText}),
+	sum:      operationRoute(sum, "Sums integer matrix", mainFile, []paramDoc{axisParam, formatParam}, vectorResponse, vectorJSON),
+	multiply: operationRoute(multiply, "Multiplies values of integer matrix", mainFile, []paramDoc{axisParam, formatParam}, vectorResponse, vectorJSON),
+	batch: operationRoute(batch, "Runs several operations on the same matrix", mainFile, []paramDoc{
+		requiredParam(queryParam(opsKey, "string", "comma separated operations, e.g. \"sum,invert\"")),
+	}, responseDoc{status: http.StatusOK, description: "results of operations", contentType: contentJSON, body: batchResponse{}}),
+	pipeline: operationRoute(pipeline, "Runs chain of transformations", mainFile, []paramDoc{
+		requiredParam(queryParam(stagesKey, "string", "comma separated \"name[:arg]\" stages, e.g. \"invert,scale:2,sum:rows\"")),
+	}, responseDoc{status: http.StatusOK, description: "matrix or result of the final aggregate stage", contentType: contentText}),
+	stats: operationRoute(stats, "Calculates descriptive statistics", mainFile, []paramDoc{
+		axisParam,
+		queryParam(percentilesKey, "string", "comma separated percentiles, e.g. \"10,50,90\""),
+	}, responseDoc{status: http.StatusOK, description: "statistics", contentType: contentJSON, body: statsResponse{}}),
+	mapping: operationRoute(mapping, "Applies function to every value", mainFile, []paramDoc{
+		requiredParam(queryParam(opKey, "string", "function", "add", "multiply", "divide", "power", "abs", "round", "clamp", "minmax", "zscore")),
+		queryParam(valueKey, "number", "argument of add, multiply, divide and power"),
+		queryParam(placesKey, "integer", "decimal places of round, from 0 to 15"),
+		queryParam(minKey, "number", "lower bound of clamp"),
+		queryParam(maxKey, "number", "upper bound of clamp"),
+	}, matrixResponse),
+	reshape: operationRoute(reshape, "Changes matrix dimensions keeping row order", mainFile, []paramDoc{
+		requiredParam(queryParam(rowsKey, "integer", "number of rows")),
+		requiredParam(queryParam(colsKey, "integer", "number of columns")),
+	}, matrixResponse),
+	slice: operationRoute(slice, "Cuts submatrix by ranges", mainFile, []paramDoc{
+		queryParam(rowsKey, "string", "range of rows, e.g. \"0:2\""),
+		queryParam(colsKey, "string", "range of columns, e.g. \"1:\""),
+	}, matrixResponse),
+	choose: operationRoute(choose, "Selects rows and columns by indexes", mainFile, []paramDoc{
+		queryParam(rowsKey, "string", "comma separated indexes of rows"),
+		queryParam(colsKey, "string", "comma separated indexes of columns"),
+	}, matrixResponse),
+	rotate: operationRoute(rotate, "Rotates matrix clockwise", mainFile, []paramDoc{
+		requiredParam(queryParam(degreesKey, "integer", "multiple of 90, negative values rotate counterclockwise")),
+	}, matrixResponse),
+	flip: operationRoute(flip, "Mirrors matrix", mainFile, []paramDoc{
+		requiredParam(queryParam(directionKey, "string", "direction", directionHorizontal, directionVertical)),
+	}, matrixResponse),
+	antiTranspose: operationRoute(antiTranspose, "Transposes matrix over the anti-diagonal", mainFile, nil, matrixResponse),
+	diagonal:      operationRoute(diagonal, "Returns main diagonal", mainFile, nil, matrixResponse),
+	triangle: operationRoute(triangle, "Keeps upper or lower triangle", mainFile, []paramDoc{
+		queryParam(partKey, "string", "triangle", partUpper, partLower),
+		queryParam(fillKey, "string", "value of removed cells, 0 by default"),
+	}, matrixResponse),
+	decompose: operationRoute(decompose, "Decomposes matrix, LU and Cholesky require square one, eigen decomposition requires symmetric one", mainFile, []paramDoc{
+		requiredParam(queryParam(methodKey, "string", "decomposition", methodLU, methodQR, methodCholesky, methodEigen, methodSVD)),
+		queryParam(formatKey, "string", "output format", formatJSON, formatZip),
+		tolParam,
+		iterParam,
+	},
+		responseDoc{status: http.StatusOK, description: "factors of decomposition", contentType: contentJSON, body: decomposition{}},
+		responseDoc{status: http.StatusOK, description: "factors as CSV files", contentType: contentZip},
+	),
+	solve: operationRoute(solve, "Solves linear system A*X = B", []string{fileKey, rhsKey}, []paramDoc{tolParam},
+		responseDoc{status: http.StatusOK, description: "solution", contentType: contentJSON, body: solution{}}),
+	power: operationRoute(power, "Raises square matrix to integer power", mainFile, []paramDoc{
+		requiredParam(queryParam(exponentKey, "integer", "exponent, negative one requires float mode")),
+		queryParam(modeKey, "string", "arithmetic", modeInt, modeFloat),
+		tolParam,
+	}, matrixResponse),
+	multi: operationRoute(multi, "Runs operation on every uploaded matrix", mainFile, []paramDoc{
+		requiredParam(queryParam(opKey, "string", "operation", "echo", "invert", "flatten", "sum", "multiply")),
+	}, responseDoc{status: http.StatusOK, description: "results by matrix name", contentType: contentJSON, body: multiResponse{}}),
+	sparseTranspose: sparseRoute(sparseTranspose, "Transposes sparse matrix", mainFile, []paramDoc{outputParam}, matrixResponse),
+	sparseSum:       sparseRoute(sparseSum, "Sums sparse matrix", mainFile, nil, scalarResponse),
+	sparseMultiply:  sparseRoute(sparseMultiply, "Multiplies values of sparse matrix", mainFile, nil, scalarResponse),
+	sparseMatmul:    sparseRoute(sparseMatmul, "Multiplies two sparse matrices", []string{fileKey, otherKey}, []paramDoc{outputParam}, matrixResponse),
+	sparseStats: sparseRoute(sparseStats, "Calculates statistics of sparse matrix", mainFile, nil,
+		responseDoc{status: http.StatusOK, description: "statistics", contentType: contentJSON, body: sparseSummary{}}),
+	jobs: {{
+		path: jobs,
+		methods: map[string]operationDoc{
+			http.MethodPost: {
+				summary: "Submits operation as async job, body and other parameters are passed to the operation",
+				files:   mainFile,
+				params:  []paramDoc{requiredParam(queryParam(opKey, "string", "operation route without slash, e.g. \"decompose\""))},
+				responses: []responseDoc{
+					{status: http.StatusAccepted, description: "job is queued, its URL is in Location header", contentType: contentJSON, body: Job{}},
+				},
+			},
+		},
+	}},
+	jobsPrefix: {
+		{
+			path: jobsPrefix + "{id}",
+			methods: map[string]operationDoc{
+				http.MethodGet: {
+					summary:   "Returns job status",
+					responses: []responseDoc{{status: http.StatusOK, description: "job", contentType: contentJSON, body: Job{}}},
+				},
+				http.MethodDelete: {
+					summary:   "Cancels job",
+					responses: []responseDoc{{status: http.StatusOK, description: "canceled job", contentType: contentJSON, body: Job{}}},
+				},
+			},
+		},
+		{
+			path: jobsPrefix + "{id}/result",
+			methods: map[string]operationDoc{
+				http.MethodGet: {
+					summary:   "Returns response of finished job",
+					responses: []responseDoc{{status: http.StatusOK, description: "response of the operation"}},
+				},
+			},
+		},
+	},
+	matrices: {{
+		path: matrices,
+		methods: map[string]operationDoc{
+			http.MethodPost: {
+				summary: "Stores matrix for use in other requests",
+				files:   mainFile,
+				params:  []paramDoc{sheetParam, queryParam(ttlKey, "string", "lifetime, e.g. \"1h\"")},
+				responses: []responseDoc{
+					{status: http.StatusCreated, description: "stored matrix", contentType: contentJSON, body: StoredMatrix{}},
+				},
+			},
+		},
+	}},
+	matricesPrefix: {{
+		path: matricesPrefix + "{id}",
+		methods: map[string]operationDoc{
+			http.MethodGet: {
+				summary: "Returns stored matrix",
+				params:  []paramDoc{formatParam},
+				responses: []responseDoc{
+					matrixResponse,
+					{status: http.StatusOK, description: "matrix with metadata", contentType: contentJSON, body: StoredMatrix{}},
+				},
+			},
+			http.MethodDelete: {
+				summary:   "Deletes stored matrix",
+				responses: []responseDoc{{status: http.StatusNoContent, description: "matrix is deleted"}},
+			},
+		},
+	}},
+	cacheStats: {{
+		path: cacheStats,
+		methods: map[string]operationDoc{
+			http.MethodGet: {
+				summary:   "Returns result cache counters",
+				responses: []responseDoc{{status: http.StatusOK, description: "counters", contentType: contentJSON, body: CacheStats{}}},
+			},
+		},
+	}},
+	openAPIPath: {{
+		path: openAPIPath,
+		methods: map[string]operationDoc{
+			http.MethodGet: {
+				summary:   "Returns this document",
+				responses: []responseDoc{{status: http.StatusOK, description: "OpenAPI document", contentType: contentJSON}},
+			},
+		},
+	}},
+}
+
+// INFO: schemas are generated from Go types of JSON responses, so they follow json tags of the types.
+type schemaBuilder struct {
+	schemas map[string]*schema
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (b *schemaBuilder) schemaOf(t reflect.Type) *schema {
+	switch {
+	case t == timeType:
+		return &schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Pointer:
+		return b.schemaOf(t.Elem())
+	case t.Kind() == reflect.Struct:
+		return b.structSchema(t)
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return &schema{Type: "string", Format: "byte"}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return &schema{Type: "array", Items: b.schemaOf(t.Elem())}
+	case t.Kind() == reflect.Map:
+		return &schema{Type: "object", AdditionalProperties: b.schemaOf(t.Elem())}
+	case t.Kind() == reflect.Bool:
+		return &schema{Type: "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return &schema{Type: "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return &schema{Type: "number"}
+	default:
+		return &schema{Type: "string"}
+	}
+}
+
+// INFO: struct schemas are put into components and referenced by name, fields without omitempty are required.
+func (b *schemaBuilder) structSchema(t reflect.Type) *schema {
+	ref := &schema{Ref: "#/components/schemas/" + t.Name()}
+	if _, ok := b.schemas[t.Name()]; ok {
+		return ref
+	}
+	s := &schema{Type: "object", Properties: map[string]*schema{}}
+	b.schemas[t.Name()] = s
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		s.Properties[name] = b.schemaOf(field.Type)
+		if !strings.Contains(opts, "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return ref
+}
+
+func (d operationDoc) requestBody() *openAPIRequestBody {
+	if len(d.files) == 0 {
+		return nil
+	}
+	formats := d.formats
+	if formats == "" {
+		formats = denseFormats
+	}
+	form := &schema{Type: "object", Properties: map[string]*schema{}}
+	for _, key := range d.files {
+		form.Properties[key] = &schema{Type: "string", Format: "binary", Description: formats + compressions}
+		form.Properties[matrixIDParam(key)] = &schema{Type: "string", Description: "id of stored matrix used instead of file"}
+	}
+	return &openAPIRequestBody{Required: true, Content: map[string]mediaType{contentMultipart: {Schema: form}}}
+}
+
+func (d operationDoc) build(b *schemaBuilder, method string, path string, secured bool, public bool) *openAPIOperation {
+	op := &openAPIOperation{
+		Summary:     d.summary,
+		OperationID: operationID(method, path),
+		RequestBody: d.requestBody(),
+		Responses:   map[string]openAPIResponse{},
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			op.Parameters = append(op.Parameters, openAPIParameter{
+				Name:     strings.Trim(segment, "{}"),
+				In:       "path",
+				Required: true,
+				Schema:   &schema{Type: "string"},
+			})
+		}
+	}
+	for _, p := range d.params {
+		op.Parameters = append(op.Parameters, openAPIParameter{
+			Name:        p.name,
+			In:          "query",
+			Description: p.description,
+			Required:    p.required,
+			Schema:      &schema{Type: p.kind, Enum: p.enum},
+		})
+	}
+	for _, r := range d.responses {
+		status := strconv.Itoa(r.status)
+		res, ok := op.Responses[status]
+		if !ok {
+			res = openAPIResponse{Description: r.description}
+		} else {
+			res.Description += " or " + r.description
+		}
+		if r.contentType != "" {
+			if res.Content == nil {
+				res.Content = map[string]mediaType{}
+			}
+			m := mediaType{}
+			if r.body != nil {
+				m.Schema = b.schemaOf(reflect.TypeOf(r.body))
+			}
+			res.Content[r.contentType] = m
+		}
+		op.Responses[status] = res
+	}
+	errorBody := map[string]mediaType{contentText: {Schema: &schema{Type: "string"}}}
+	op.Responses["4XX"] = openAPIResponse{Description: "invalid request, the reason is in body", Content: errorBody}
+	op.Responses["5XX"] = openAPIResponse{Description: "server error or overload, the reason is in body", Content: errorBody}
+	if secured && !public {
+		// INFO: auth middleware answers with JSON unlike handlers.
+		authBody := map[string]mediaType{contentJSON: {Schema: b.schemaOf(reflect.TypeOf(authError{}))}}
+		op.Responses[strconv.Itoa(http.StatusUnauthorized)] = openAPIResponse{
+			Description: "missing or invalid credentials", Content: authBody,
+		}
+		op.Responses[strconv.Itoa(http.StatusForbidden)] = openAPIResponse{
+			Description: "operation isn't permitted for the client", Content: authBody,
+		}
+	}
+	if secured && public {
+		op.Security = &[]map[string][]string{}
+	}
+	return op
+}
+
+// INFO: operationID joins method and path words, e.g. "getJobsIdResult".
+func operationID(method string, path string) string {
+	id := strings.ToLower(method)
+	for _, word := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '.'
+	}) {
+		id += strings.ToUpper(word[:1]) + word[1:]
+	}
+	return id
+}
+
+// INFO: builds document of routes registered in the router, so disabled or not registered routes aren't
+// described. Security schemes are added when authentication is enabled.
+func (rout *Router) openAPI() openAPIDoc {
+	doc := openAPIDoc{
+		OpenAPI: openAPIVersion,
+		Info: openAPIInfo{
+			Title:       apiTitle,
+			Version:     apiVersion,
+			Description: "Operations on matrices uploaded as multipart form files or stored in advance.",
+		},
+		Paths: map[string]map[string]*openAPIOperation{},
+	}
+	b := &schemaBuilder{schemas: map[string]*schema{}}
+
+	patterns := append([]string(nil), rout.routes...)
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		docs, ok := routeDocs[pattern]
+		if !ok {
+			rout.log.Warn("route isn't documented", zap.String("pattern", pattern))
+			continue
+		}
+		for _, p := range docs {
+			item := map[string]*openAPIOperation{}
+			for method, d := range p.methods {
+				item[strings.ToLower(method)] = d.build(b, method, p.path, rout.auth != nil, publicRoutes[pattern])
+			}
+			doc.Paths[p.path] = item
+		}
+	}
+
+	doc.Components.Schemas = b.schemas
+	if rout.auth != nil {
+		doc.Components.SecuritySchemes = map[string]securityScheme{
+			apiKeyScheme: {Type: "apiKey", Name: apiKeyHeader, In: "header"},
+			bearerScheme: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+		}
+		doc.Security = []map[string][]string{{apiKeyScheme: {}}, {bearerScheme: {}}}
+	}
+	return doc
+}
+
+func (rout *Router) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := writeJSON(w, http.StatusOK, rout.openAPI())
+	if err != nil {
+		rout.log.Error("writing response failed", zap.Error(err))
+	}
+}
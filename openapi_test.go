@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// INFO: collects all "$ref" values of the document.
+func collectRefs(v any, refs map[string]bool) {
+	switch value := v.(type) {
+	case map[string]any:
+		for k, item := range value {
+			if ref, ok := item.(string); ok && k == "$ref" {
+				refs[ref] = true
+			}
+			collectRefs(item, refs)
+		}
+	case []any:
+		for _, item := range value {
+			collectRefs(item, refs)
+		}
+	}
+}
+
+func TestRouter_openAPI(t *testing.T) {
+	router, err := setupRouter()
+	assert.NoError(t, err)
+	defer router.Close()
+
+	registered := map[string]bool{}
+	for _, pattern := range router.routes {
+		registered[pattern] = true
+		assert.Contains(t, routeDocs, pattern, "route %q is registered without documentation", pattern)
+	}
+	for pattern := range routeDocs {
+		assert.True(t, registered[pattern], "documented route %q isn't registered", pattern)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, testURL+openAPIPath, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+	var doc struct {
+		OpenAPI    string                               `json:"openapi"`
+		Paths      map[string]map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas         map[string]any `json:"schemas"`
+			SecuritySchemes map[string]any `json:"securitySchemes"`
+		} `json:"components"`
+	}
+	body := w.Body.Bytes()
+	assert.NoError(t, json.Unmarshal(body, &doc))
+	assert.Equal(t, openAPIVersion, doc.OpenAPI)
+	assert.Empty(t, doc.Components.SecuritySchemes)
+
+	for _, docs := range routeDocs {
+		for _, p := range docs {
+			for method := range p.methods {
+				assert.Contains(t, doc.Paths[p.path], strings.ToLower(method), "%s %s", method, p.path)
+			}
+		}
+	}
+	assert.Contains(t, doc.Paths[jobsPrefix+"{id}/result"], "get")
+
+	var raw any
+	assert.NoError(t, json.Unmarshal(body, &raw))
+	refs := map[string]bool{}
+	collectRefs(raw, refs)
+	assert.NotEmpty(t, refs)
+	for ref := range refs {
+		assert.Contains(t, doc.Components.Schemas, strings.TrimPrefix(ref, "#/components/schemas/"))
+	}
+}
+
+func TestRouter_openAPIAuth(t *testing.T) {
+	logger, err := zap.NewProduction()
+	assert.NoError(t, err)
+	keys, err := LoadAPIKeys(keysPath)
+	assert.NoError(t, err)
+	router, err := NewRouter(logger, WithAPIKeys(keys...))
+	assert.NoError(t, err)
+	router.InitRoutes()
+	defer router.Close()
+
+	tt := []struct {
+		name         string
+		providedPath string
+		providedVerb string
+		expectedCode int
+	}{
+		{
+			name:         "fail: method isn't allowed",
+			providedPath: openAPIPath,
+			providedVerb: http.MethodPost,
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:         "fail: operations still require credentials",
+			providedPath: cacheStats,
+			providedVerb: http.MethodGet,
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "success: document is public",
+			providedPath: openAPIPath,
+			providedVerb: http.MethodGet,
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.providedVerb, testURL+tc.providedPath, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+		})
+	}
+
+	doc := router.openAPI()
+	assert.Contains(t, doc.Components.SecuritySchemes, apiKeyScheme)
+	assert.Contains(t, doc.Components.SecuritySchemes, bearerScheme)
+	assert.NotNil(t, doc.Paths[openAPIPath]["get"].Security)
+	assert.Nil(t, doc.Paths[sum]["post"].Security)
+	assert.Contains(t, doc.Paths[sum]["post"].Responses[strconv.Itoa(http.StatusUnauthorized)].Content, contentJSON)
+	assert.Contains(t, doc.Paths[sum]["post"].Responses[strconv.Itoa(http.StatusForbidden)].Content, contentJSON)
+}
+
+func Test_sparseRouteDocs(t *testing.T) {
+	router, err := setupRouter()
+	assert.NoError(t, err)
+	defer router.Close()
+	doc := router.openAPI()
+
+	paramNames := func(op *openAPIOperation) []string {
+		var names []string
+		for _, p := range op.Parameters {
+			names = append(names, p.Name)
+		}
+		return names
+	}
+	fileDescription := func(op *openAPIOperation) string {
+		return op.RequestBody.Content[contentMultipart].Schema.Properties[fileKey].Description
+	}
+
+	sparseOp := doc.Paths[sparseMatmul]["post"]
+	assert.Subset(t, paramNames(sparseOp), []string{rowsKey, colsKey, outputKey})
+	assert.NotContains(t, paramNames(sparseOp), sheetKey)
+	assert.Equal(t, sparseFormats+compressions, fileDescription(sparseOp))
+	assert.Contains(t, sparseOp.RequestBody.Content[contentMultipart].Schema.Properties, otherKey)
+
+	denseOp := doc.Paths[sum]["post"]
+	assert.Contains(t, paramNames(denseOp), sheetKey)
+	assert.Equal(t, denseFormats+compressions, fileDescription(denseOp))
+}
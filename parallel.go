@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// INFO: matrices with fewer cells are processed serially by default, goroutines cost more than they save for
+// them.
+const defaultParallelThreshold = 1 << 14
+
+// INFO: parallelism of row-block computations of a request, see WithWorkers and WithParallelThreshold.
+type parallelism struct {
+	workers int
+	// INFO: matrices with fewer cells are processed serially.
+	threshold int
+}
+
+type parallelismContextKey struct{}
+
+func withParallelism(ctx context.Context, p parallelism) context.Context {
+	return context.WithValue(ctx, parallelismContextKey{}, p)
+}
+
+// INFO: parallelism of the request, GOMAXPROCS workers and default threshold when it isn't set.
+func parallelismFrom(ctx context.Context) parallelism {
+	if p, ok := ctx.Value(parallelismContextKey{}).(parallelism); ok {
+		return p
+	}
+	return parallelism{workers: runtime.GOMAXPROCS(0), threshold: defaultParallelThreshold}
+}
+
+// INFO: splits rows into contiguous blocks, one per worker. Small matrices get a single block.
+func rowBlocks(ctx context.Context, rows int, cells int) []indexRange {
+	p := parallelismFrom(ctx)
+	n := p.workers
+	if cells < p.threshold || n < 1 {
+		n = 1
+	}
+	if n > rows {
+		n = rows
+	}
+
+	blocks := make([]indexRange, 0, n)
+	for i := 0; i < n; i++ {
+		blocks = append(blocks, indexRange{start: rows * i / n, end: rows * (i + 1) / n})
+	}
+	return blocks
+}
+
+// INFO: runs fn for every block in its own goroutine. Returns error of the first failed block in row order,
+// so the result doesn't depend on scheduling. Nothing is started once ctx is done, fn checks ctx between rows
+// itself, so canceled request or job stops the started blocks too.
+func parallelBlocks(ctx context.Context, blocks []indexRange, fn func(i int, block indexRange) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	progress := progressFrom(ctx)
+	progress.add(len(blocks))
+	if len(blocks) == 1 {
+		defer progress.finish()
+		return fn(0, blocks[0])
+	}
+
+	errs := make([]error, len(blocks))
+	var wg sync.WaitGroup
+	for i, block := range blocks {
+		wg.Add(1)
+		go func(i int, block indexRange) {
+			defer wg.Done()
+			defer progress.finish()
+			errs[i] = fn(i, block)
+		}(i, block)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// INFO: shortcut of parallelBlocks for row loops that don't need block index.
+func parallelRows(ctx context.Context, rows int, cells int, fn func(block indexRange) error) error {
+	return parallelBlocks(ctx, rowBlocks(ctx, rows, cells), func(_ int, block indexRange) error {
+		return fn(block)
+	})
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+// INFO: returns context with parallelism of the test.
+func workersContext(workers int, threshold int) context.Context {
+	return withParallelism(context.Background(), parallelism{workers: workers, threshold: threshold})
+}
+
+func randomMatrix(rows int, cols int, limit int) [][]string {
+	rnd := rand.New(rand.NewSource(42))
+	res := make([][]string, rows)
+	for i := range res {
+		res[i] = make([]string, cols)
+		for j := range res[i] {
+			res[i][j] = strconv.Itoa(rnd.Intn(2*limit+1) - limit)
+		}
+	}
+	return res
+}
+
+func Test_rowBlocks(t *testing.T) {
+	ctx := workersContext(4, 100)
+
+	tt := []struct {
+		name           string
+		providedRows   int
+		providedCells  int
+		expectedResult []indexRange
+	}{
+		{
+			name:           "success: small matrix in single block",
+			providedRows:   10,
+			providedCells:  99,
+			expectedResult: []indexRange{{start: 0, end: 10}},
+		},
+		{
+			name:           "success: fewer rows than workers",
+			providedRows:   2,
+			providedCells:  1000,
+			expectedResult: []indexRange{{start: 0, end: 1}, {start: 1, end: 2}},
+		},
+		{
+			name:          "success: uneven blocks",
+			providedRows:  10,
+			providedCells: 100,
+			expectedResult: []indexRange{
+				{start: 0, end: 2}, {start: 2, end: 5}, {start: 5, end: 7}, {start: 7, end: 10},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedResult, rowBlocks(ctx, tc.providedRows, tc.providedCells))
+		})
+	}
+}
+
+func Test_parallelBlocks(t *testing.T) {
+	errFirst, errSecond := errors.New("first"), errors.New("second")
+	blocks := []indexRange{{0, 1}, {1, 2}, {2, 3}, {3, 4}}
+
+	for i := 0; i < 10; i++ {
+		err := parallelBlocks(context.Background(), blocks, func(i int, _ indexRange) error {
+			switch i {
+			case 1:
+				return errFirst
+			case 3:
+				return errSecond
+			}
+			return nil
+		})
+		assert.ErrorIs(t, err, errFirst)
+	}
+}
+
+func Test_parallelBlocks_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(workersContext(4, 1))
+	cancel()
+
+	called := false
+	err := parallelBlocks(ctx, []indexRange{{0, 1}, {1, 2}}, func(int, indexRange) error {
+		called = true
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, called)
+
+	dense, err := matrixToFloat(randomMatrix(50, 50, 3))
+	assert.NoError(t, err)
+	_, err = mulDense(ctx, dense, dense)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = sumMatrix(ctx, randomMatrix(50, 50, 3))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, transposeInPlace(ctx, randomMatrix(50, 50, 3)), context.Canceled)
+}
+
+func Test_parallelBlocks_progress(t *testing.T) {
+	progress := &jobProgress{}
+	ctx := context.WithValue(workersContext(4, 1), jobProgressKey{}, progress)
+
+	_, err := sumMatrix(ctx, randomMatrix(8, 8, 3))
+	assert.NoError(t, err)
+	// INFO: conversion and summation take 4 blocks each.
+	assert.Equal(t, int64(8), progress.total)
+	assert.Equal(t, 1.0, progress.ratio())
+}
+
+func TestRouter_parallelism(t *testing.T) {
+	logger := zap.NewNop()
+	tt := []struct {
+		name                string
+		providedOptions     []Option
+		expectedParallelism parallelism
+	}{
+		{
+			name:                "success: GOMAXPROCS and default threshold",
+			expectedParallelism: parallelism{workers: runtime.GOMAXPROCS(0), threshold: defaultParallelThreshold},
+		},
+		{
+			name:                "success: routers keep their own settings",
+			providedOptions:     []Option{WithWorkers(3), WithParallelThreshold(100)},
+			expectedParallelism: parallelism{workers: 3, threshold: 100},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			router, err := NewRouter(logger, tc.providedOptions...)
+			assert.NoError(t, err)
+			defer router.Close()
+			var res parallelism
+			router.handle("/parallelism", func(w http.ResponseWriter, r *http.Request) {
+				res = parallelismFrom(r.Context())
+			})
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, testURL+"/parallelism", nil))
+			assert.Equal(t, tc.expectedParallelism, res)
+		})
+	}
+}
+
+func Test_parallelMatchesSerial(t *testing.T) {
+	matrix := randomMatrix(150, 150, 3)
+	dense, err := matrixToFloat(matrix)
+	assert.NoError(t, err)
+	ints, err := matrixToInt(context.Background(), matrix)
+	assert.NoError(t, err)
+
+	calculate := func(ctx context.Context) []any {
+		sum, sumErr := sumMatrix(ctx, matrix)
+		product, productErr := multiplyMatrix(ctx, matrix)
+		rowSums, rowErr := sumAxis(ctx, matrix, axisRows)
+		colProducts, colErr := multiplyAxis(ctx, matrix, axisCols)
+		converted, convertErr := matrixToInt(ctx, matrix)
+		intProduct, intErr := mulIntMatrix(ctx, ints, ints)
+		_, invalidErr := matrixToInt(ctx, append(randomMatrix(149, 150, 3), append(make([]string, 149), "x")))
+		inverted, invertErr := invertMatrix(ctx, matrix)
+		transposed, transposeErr := transposeDense(ctx, dense)
+		denseProduct, denseErr := mulDense(ctx, dense, dense)
+		inPlace := cloneMatrix(matrix)
+		inPlaceErr := transposeInPlace(ctx, inPlace)
+		return []any{
+			sum, sumErr, product, productErr, converted, convertErr, intProduct, intErr, invalidErr,
+			inverted, invertErr, transposed, transposeErr, denseProduct, denseErr, rowSums, rowErr, colProducts, colErr,
+			inPlace, inPlaceErr,
+		}
+	}
+
+	serial := calculate(workersContext(1, defaultParallelThreshold))
+	parallel := calculate(workersContext(7, 1))
+
+	assert.Equal(t, serial, parallel)
+	assert.ErrorIs(t, serial[8].(error), errNotIntValue)
+	assert.Equal(t, serial[9], serial[19])
+}
+
+func benchmarkParallel(b *testing.B, fn func(ctx context.Context)) {
+	for _, bc := range []struct {
+		name    string
+		workers int
+	}{
+		{name: "serial", workers: 1},
+		{name: "parallel", workers: runtime.GOMAXPROCS(0)},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			ctx := workersContext(bc.workers, defaultParallelThreshold)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				fn(ctx)
+			}
+		})
+	}
+}
+
+// INFO: /sum and /multiply handlers fold along the requested axis, whole matrix is folded the same way.
+func BenchmarkParallelSumAxis(b *testing.B) {
+	matrix := randomMatrix(1000, 1000, 100)
+	for _, axis := range []string{axisAll, axisRows, axisCols} {
+		b.Run(axis, func(b *testing.B) {
+			benchmarkParallel(b, func(ctx context.Context) {
+				_, _ = sumAxis(ctx, matrix, axis)
+			})
+		})
+	}
+}
+
+func BenchmarkParallelMultiplyAxis(b *testing.B) {
+	matrix := randomMatrix(1000, 1000, 100)
+	benchmarkParallel(b, func(ctx context.Context) {
+		_, _ = multiplyAxis(ctx, matrix, axisAll)
+	})
+}
+
+func BenchmarkParallelMatrixToInt(b *testing.B) {
+	matrix := randomMatrix(1000, 1000, 100)
+	benchmarkParallel(b, func(ctx context.Context) {
+		_, _ = matrixToInt(ctx, matrix)
+	})
+}
+
+func BenchmarkParallelInvertMatrix(b *testing.B) {
+	matrix := randomMatrix(1000, 1000, 100)
+	benchmarkParallel(b, func(ctx context.Context) {
+		_, _ = invertMatrix(ctx, matrix)
+	})
+}
+
+func BenchmarkParallelTransposeInPlace(b *testing.B) {
+	matrix := randomMatrix(1000, 1000, 100)
+	benchmarkParallel(b, func(ctx context.Context) {
+		_ = transposeInPlace(ctx, matrix)
+	})
+}
+
+func BenchmarkParallelTransposeDense(b *testing.B) {
+	dense, _ := matrixToFloat(randomMatrix(1000, 1000, 100))
+	benchmarkParallel(b, func(ctx context.Context) {
+		_, _ = transposeDense(ctx, dense)
+	})
+}
+
+func BenchmarkParallelMulDense(b *testing.B) {
+	dense, _ := matrixToFloat(randomMatrix(200, 200, 100))
+	benchmarkParallel(b, func(ctx context.Context) {
+		_, _ = mulDense(ctx, dense, dense)
+	})
+}
+
+func BenchmarkParallelMulIntMatrix(b *testing.B) {
+	ints, _ := matrixToInt(context.Background(), randomMatrix(200, 200, 100))
+	benchmarkParallel(b, func(ctx context.Context) {
+		_, _ = mulIntMatrix(ctx, ints, ints)
+	})
+}
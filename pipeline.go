@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	stagesKey = "stages"
+
+	stageArgSep = ":"
+)
+
+var (
+	errNoStages         = errors.New("no stages provided, use \"stages\" parameter with comma separated list")
+	errUnknownStage     = errors.New("unknown stage")
+	errAggregateNotLast = errors.New("aggregate stage is allowed only at the end of pipeline")
+	errInvalidStageArg  = errors.New("invalid stage argument")
+)
+
+// INFO: shape is a number of rows and columns of the matrix. Used to validate the whole pipeline before
+// any stage is executed.
+type shape struct {
+	rows int
+	cols int
+}
+
+func (s shape) String() string {
+	return fmt.Sprintf("%dx%d", s.rows, s.cols)
+}
+
+func matrixShape(matrix [][]string) shape {
+	if len(matrix) == 0 {
+		return shape{}
+	}
+	return shape{rows: len(matrix), cols: len(matrix[0])}
+}
+
+// INFO: transform is a matrix-to-matrix stage. Output shape is calculated from the input one and stage
+// argument without touching the data, so invalid arguments are also reported there.
+type transform struct {
+	shape func(in shape, arg string) (shape, error)
+	apply func(ctx context.Context, matrix [][]string, arg string) ([][]string, error)
+}
+
+// INFO: aggregate is a final stage which produces the response instead of the matrix.
+type aggregate struct {
+	validate func(in shape, arg string) error
+	apply    func(ctx context.Context, matrix [][]string, arg string) (string, error)
+}
+
+var transforms = map[string]transform{
+	"invert": {
+		shape: func(in shape, arg string) (shape, error) {
+			return shape{rows: in.cols, cols: in.rows}, noArg(arg)
+		},
+		apply: func(ctx context.Context, matrix [][]string, _ string) ([][]string, error) {
+			return invertMatrix(ctx, matrix)
+		},
+	},
+	"rotate": {
+		shape: func(in shape, arg string) (shape, error) {
+			turns, err := parseDegrees(arg)
+			if err != nil {
+				return shape{}, fmt.Errorf("%w: %s", errInvalidStageArg, err.Error())
+			}
+			if turns%2 == 1 {
+				return shape{rows: in.cols, cols: in.rows}, nil
+			}
+			return in, nil
+		},
+		apply: func(_ context.Context, matrix [][]string, arg string) ([][]string, error) {
+			turns, err := parseDegrees(arg)
+			if err != nil {
+				return nil, err
+			}
+			return rotateMatrix(matrix, turns), nil
+		},
+	},
+	"flip": {
+		shape: func(in shape, arg string) (shape, error) {
+			_, err := parseDirection(arg)
+			if err != nil {
+				return shape{}, fmt.Errorf("%w: %s", errInvalidStageArg, err.Error())
+			}
+			return in, nil
+		},
+		apply: func(_ context.Context, matrix [][]string, arg string) ([][]string, error) {
+			direction, err := parseDirection(arg)
+			if err != nil {
+				return nil, err
+			}
+			return flipMatrix(matrix, direction), nil
+		},
+	},
+	"antitranspose": {
+		shape: func(in shape, arg string) (shape, error) {
+			return shape{rows: in.cols, cols: in.rows}, noArg(arg)
+		},
+		apply: func(_ context.Context, matrix [][]string, _ string) ([][]string, error) {
+			return antiTransposeMatrix(matrix), nil
+		},
+	},
+	"reshape": {
+		shape: func(in shape, arg string) (shape, error) {
+			out, err := parseShape(arg)
+			if err != nil {
+				return shape{}, fmt.Errorf("%w: %s", errInvalidStageArg, err.Error())
+			}
+			return out, checkReshape(in, out)
+		},
+		apply: func(_ context.Context, matrix [][]string, arg string) ([][]string, error) {
+			out, err := parseShape(arg)
+			if err != nil {
+				return nil, err
+			}
+			return reshapeMatrix(matrix, out)
+		},
+	},
+	"scale": {
+		shape: func(in shape, arg string) (shape, error) {
+			_, err := intArg(arg)
+			return in, err
+		},
+		apply: func(_ context.Context, matrix [][]string, arg string) ([][]string, error) {
+			factor, err := intArg(arg)
+			if err != nil {
+				return nil, err
+			}
+			return scaleMatrix(matrix, factor)
+		},
+	},
+}
+
+var aggregates = map[string]aggregate{
+	"sum": {
+		validate: axisArg,
+		apply: func(ctx context.Context, matrix [][]string, arg string) (string, error) {
+			axis, err := parseAxis(arg)
+			if err != nil {
+				return "", err
+			}
+			res, err := sumAxis(ctx, matrix, axis)
+			if err != nil {
+				return "", err
+			}
+			return formatVector(res, axis, formatCSV), nil
+		},
+	},
+	"multiply": {
+		validate: axisArg,
+		apply: func(ctx context.Context, matrix [][]string, arg string) (string, error) {
+			axis, err := parseAxis(arg)
+			if err != nil {
+				return "", err
+			}
+			res, err := multiplyAxis(ctx, matrix, axis)
+			if err != nil {
+				return "", err
+			}
+			return formatVector(res, axis, formatCSV), nil
+		},
+	},
+	"flatten": {
+		validate: func(_ shape, arg string) error {
+			if arg != "" && arg != orderRow && arg != orderColumn && arg != orderSnake && arg != orderDiagonal {
+				return fmt.Errorf("%w: %s", errInvalidStageArg, errInvalidOrder.Error())
+			}
+			return nil
+		},
+		apply: func(_ context.Context, matrix [][]string, arg string) (string, error) {
+			items, err := flattenMatrix(matrix, arg)
+			if err != nil {
+				return "", err
+			}
+			return joinFlat(items, layoutRow, defaultSep)
+		},
+	},
+}
+
+func noArg(arg string) error {
+	if arg != "" {
+		return fmt.Errorf("%w: unexpected %q", errInvalidStageArg, arg)
+	}
+	return nil
+}
+
+func axisArg(_ shape, arg string) error {
+	_, err := parseAxis(arg)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errInvalidStageArg, err.Error())
+	}
+	return nil
+}
+
+func intArg(arg string) (int, error) {
+	v, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, fmt.Errorf("%w: integer expected, got %q", errInvalidStageArg, arg)
+	}
+	return v, nil
+}
+
+type pipelineStage struct {
+	name      string
+	arg       string
+	transform *transform
+	aggregate *aggregate
+}
+
+type stageChain []pipelineStage
+
+// INFO: parses comma separated stages in "name[:arg]" format, e.g. "invert,scale:2,reshape:1x9,sum:rows".
+func parsePipeline(raw string) (stageChain, error) {
+	var p stageChain
+	for _, spec := range strings.Split(raw, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		name, arg, _ := strings.Cut(spec, stageArgSep)
+
+		if len(p) > 0 && p[len(p)-1].aggregate != nil {
+			return nil, errAggregateNotLast
+		}
+
+		if t, ok := transforms[name]; ok {
+			p = append(p, pipelineStage{name: name, arg: arg, transform: &t})
+			continue
+		}
+		if a, ok := aggregates[name]; ok {
+			p = append(p, pipelineStage{name: name, arg: arg, aggregate: &a})
+			continue
+		}
+		return nil, fmt.Errorf("%w: %q", errUnknownStage, name)
+	}
+
+	if len(p) == 0 {
+		return nil, errNoStages
+	}
+	return p, nil
+}
+
+// INFO: checks that every stage accepts the shape produced by the previous one.
+func (p stageChain) validate(in shape) error {
+	for _, stage := range p {
+		out, err := in, error(nil)
+		if stage.aggregate != nil {
+			err = stage.aggregate.validate(in, stage.arg)
+		} else {
+			out, err = stage.transform.shape(in, stage.arg)
+		}
+		if err != nil {
+			return fmt.Errorf("stage %q on %s matrix: %w", stage.name, in, err)
+		}
+		in = out
+	}
+	return nil
+}
+
+// INFO: executes stages one by one. If pipeline doesn't end with aggregate the resulting matrix is
+// returned in matrix view.
+func (p stageChain) run(ctx context.Context, matrix [][]string) (string, error) {
+	var err error
+	for _, stage := range p {
+		if stage.aggregate != nil {
+			return stage.aggregate.apply(ctx, matrix, stage.arg)
+		}
+		matrix, err = stage.transform.apply(ctx, matrix, stage.arg)
+		if err != nil {
+			return "", fmt.Errorf("stage %q: %w", stage.name, err)
+		}
+	}
+	return convertToMatrixString(matrix), nil
+}
+
+func (rout *Router) Pipeline(w http.ResponseWriter, r *http.Request) {
+	matrix, err := rout.extractData(r, fileKey)
+	if err != nil {
+		rout.log.Error("extracting data from .csv file failed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	p, err := parsePipeline(r.FormValue(stagesKey))
+	if err == nil {
+		err = p.validate(matrixShape(matrix))
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := p.run(r.Context(), matrix)
+	rout.log.Info("Pipeline command called", zap.String("stages", r.FormValue(stagesKey)))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+
+	_, err = fmt.Fprint(w, response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
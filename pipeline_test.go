@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_parsePipeline(t *testing.T) {
+	tt := []struct {
+		name          string
+		providedRaw   string
+		expectedNames []string
+		expectedErr   error
+	}{
+		{
+			name:          "fail: no stages",
+			providedRaw:   "",
+			expectedNames: nil,
+			expectedErr:   errNoStages,
+		},
+		{
+			name:          "fail: unknown stage",
+			providedRaw:   "invert,spin",
+			expectedNames: nil,
+			expectedErr:   errUnknownStage,
+		},
+		{
+			name:          "fail: aggregate in the middle",
+			providedRaw:   "sum,invert",
+			expectedNames: nil,
+			expectedErr:   errAggregateNotLast,
+		},
+		{
+			name:          "success: stages parsed",
+			providedRaw:   "invert, scale:2,sum",
+			expectedNames: []string{"invert", "scale", "sum"},
+			expectedErr:   nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := parsePipeline(tc.providedRaw)
+			var names []string
+			for _, stage := range res {
+				names = append(names, stage.name)
+			}
+			assert.Equal(t, tc.expectedNames, names)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func Test_stageChain_validate(t *testing.T) {
+	tt := []struct {
+		name        string
+		providedRaw string
+		expectedErr error
+	}{
+		{
+			name:        "fail: non-int scale factor",
+			providedRaw: "invert,scale:x",
+			expectedErr: errInvalidStageArg,
+		},
+		{
+			name:        "fail: unexpected argument",
+			providedRaw: "invert:1",
+			expectedErr: errInvalidStageArg,
+		},
+		{
+			name:        "fail: invalid aggregate axis",
+			providedRaw: "invert,sum:diagonal",
+			expectedErr: errInvalidStageArg,
+		},
+		{
+			name:        "fail: reshape with wrong element count",
+			providedRaw: "reshape:1x3",
+			expectedErr: errElementCount,
+		},
+		{
+			name:        "fail: reshape with overflowing element count",
+			providedRaw: "reshape:3689348814741910324x5",
+			expectedErr: errElementCount,
+		},
+		{
+			name:        "success: valid pipeline",
+			providedRaw: "invert,scale:2,sum",
+			expectedErr: nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := parsePipeline(tc.providedRaw)
+			assert.NoError(t, err)
+
+			err = p.validate(shape{rows: 2, cols: 2})
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func Test_stageChain_run(t *testing.T) {
+	validMatrix := [][]string{{"1", "2"}, {"3", "4"}}
+	invalidMatrix := [][]string{{"1", "b"}, {"3", "4"}}
+
+	tt := []struct {
+		name           string
+		providedMatrix [][]string
+		providedRaw    string
+		expectedResult string
+		expectedErr    error
+	}{
+		{
+			name:           "fail: matrix with non-int values",
+			providedMatrix: invalidMatrix,
+			providedRaw:    "invert,scale:2",
+			expectedResult: "",
+			expectedErr:    errNotIntValue,
+		},
+		{
+			name:           "success: transforms only, matrix view returned",
+			providedMatrix: validMatrix,
+			providedRaw:    "invert,scale:2",
+			expectedResult: "2,6\n4,8\n",
+			expectedErr:    nil,
+		},
+		{
+			name:           "success: ended with per row aggregate",
+			providedMatrix: validMatrix,
+			providedRaw:    "invert,scale:2,sum:rows",
+			expectedResult: "8\n12\n",
+			expectedErr:    nil,
+		},
+		{
+			name:           "success: structural transforms",
+			providedMatrix: validMatrix,
+			providedRaw:    "rotate:90,flip:horizontal",
+			expectedResult: "1,3\n2,4\n",
+			expectedErr:    nil,
+		},
+		{
+			name:           "success: reshaped before aggregate",
+			providedMatrix: validMatrix,
+			providedRaw:    "reshape:1x4,sum:cols",
+			expectedResult: "1,2,3,4\n",
+			expectedErr:    nil,
+		},
+		{
+			name:           "success: ended with aggregate",
+			providedMatrix: validMatrix,
+			providedRaw:    "scale:3,flatten:column",
+			expectedResult: "3,9,6,12\n",
+			expectedErr:    nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := parsePipeline(tc.providedRaw)
+			assert.NoError(t, err)
+
+			res, err := p.run(context.Background(), tc.providedMatrix)
+			assert.Equal(t, tc.expectedResult, res)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestRouter_Pipeline(t *testing.T) {
+	router, err := setupRouter()
+	assert.NoError(t, err)
+
+	successReq, successW, err := createReq(validPath, testURL+"?stages=invert,scale:2,sum")
+	assert.NoError(t, err)
+	successReq.Header.Set("Content-Type", successW.FormDataContentType())
+
+	invalidReq, invalidW, err := createReq(validPath, testURL+"?stages=invert,scale:two")
+	assert.NoError(t, err)
+	invalidReq.Header.Set("Content-Type", invalidW.FormDataContentType())
+
+	unsupportedReq, unsupportedW, err := createReq(unsupportedPath, testURL+"?stages=invert")
+	assert.NoError(t, err)
+	unsupportedReq.Header.Set("Content-Type", unsupportedW.FormDataContentType())
+
+	tt := []struct {
+		name         string
+		providedReq  *http.Request
+		expectedBody string
+		expectedCode int
+	}{
+		{
+			name:         "fail: extract data - BadRequest",
+			providedReq:  unsupportedReq,
+			expectedBody: errFileExtension.Error() + "\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "fail: invalid stage argument - BadRequest",
+			providedReq:  invalidReq,
+			expectedBody: "stage \"scale\" on 3x3 matrix: invalid stage argument: integer expected, got \"two\"\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "success: pipeline executed",
+			providedReq:  successReq,
+			expectedBody: "90\n",
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.Pipeline(w, tc.providedReq)
+
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
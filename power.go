@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+const (
+	exponentKey = "n"
+	modeKey     = "mode"
+
+	modeInt   = "int"
+	modeFloat = "float"
+)
+
+var (
+	errInvalidExponent  = errors.New("invalid exponent, should be an integer")
+	errInvalidMode      = errors.New("invalid mode, should be one of \"int\", \"float\"")
+	errNegativeExponent = errors.New("negative exponent requires float mode")
+	errOverflow         = errors.New("result overflows")
+)
+
+func mulChecked(a, b int) (int, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	c := a * b
+	if c/b != a || (a == -1 && b == math.MinInt) || (b == -1 && a == math.MinInt) {
+		return 0, false
+	}
+	return c, true
+}
+
+func addChecked(a, b int) (int, bool) {
+	c := a + b
+	if (b > 0 && c < a) || (b < 0 && c > a) {
+		return 0, false
+	}
+	return c, true
+}
+
+// INFO: multiplies integer matrices, blocks of rows are calculated in parallel. Returns errOverflow if any
+// intermediate value doesn't fit into int.
+func mulIntMatrix(ctx context.Context, a, b [][]int) ([][]int, error) {
+	res := make([][]int, len(a))
+	err := parallelRows(ctx, len(a), len(a)*len(b)*len(b[0]), func(block indexRange) error {
+		for i := block.start; i < block.end; i++ {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			res[i] = make([]int, len(b[0]))
+			for j := range b[0] {
+				var sum int
+				for k := range b {
+					prod, ok := mulChecked(a[i][k], b[k][j])
+					if ok {
+						sum, ok = addChecked(sum, prod)
+					}
+					if !ok {
+						return errOverflow
+					}
+				}
+				res[i][j] = sum
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func identityInt(n int) [][]int {
+	res := make([][]int, n)
+	for i := range res {
+		res[i] = make([]int, n)
+		res[i][i] = 1
+	}
+	return res
+}
+
+// INFO: calculates A^n of square matrix with exponentiation by squaring, so only O(log n) multiplications
+// are needed.
+func powIntMatrix(ctx context.Context, a [][]int, n int) ([][]int, error) {
+	if n < 0 {
+		return nil, errNegativeExponent
+	}
+
+	res := identityInt(len(a))
+	base := a
+	var err error
+	for n > 0 {
+		if n%2 == 1 {
+			res, err = mulIntMatrix(ctx, res, base)
+			if err != nil {
+				return nil, err
+			}
+		}
+		n /= 2
+		if n > 0 {
+			base, err = mulIntMatrix(ctx, base, base)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return res, nil
+}
+
+// INFO: inverts square matrix with LU decomposition.
+func inverse(a [][]float64, tol float64) ([][]float64, error) {
+	perm, l, u, _ := luDecompose(a)
+	threshold := tol * math.Max(maxAbs(a), 1)
+	for i := range u {
+		if math.Abs(u[i][i]) < threshold {
+			return nil, errSingularMatrix
+		}
+	}
+	return luSolve(perm, l, u, identity(len(a))), nil
+}
+
+// INFO: float version of powIntMatrix, negative exponent is calculated as power of the inverse matrix.
+func powFloatMatrix(ctx context.Context, a [][]float64, n int, tol float64) ([][]float64, error) {
+	base := a
+	if n < 0 {
+		inv, err := inverse(a, tol)
+		if err != nil {
+			return nil, err
+		}
+		base, n = inv, -n
+	}
+
+	res := identity(len(a))
+	var err error
+	for n > 0 {
+		if n%2 == 1 {
+			res, err = mulDense(ctx, res, base)
+			if err != nil {
+				return nil, err
+			}
+		}
+		n /= 2
+		if n > 0 {
+			base, err = mulDense(ctx, base, base)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for i := range res {
+		for _, v := range res[i] {
+			if math.IsInf(v, 0) || math.IsNaN(v) {
+				return nil, errOverflow
+			}
+		}
+	}
+	return res, nil
+}
+
+func intToMatrix(a [][]int) [][]string {
+	res := make([][]string, len(a))
+	for i := range a {
+		res[i] = make([]string, len(a[i]))
+		for j, v := range a[i] {
+			res[i][j] = strconv.Itoa(v)
+		}
+	}
+	return res
+}
+
+func powerMatrix(ctx context.Context, matrix [][]string, n int, mode string, tol float64) ([][]string, error) {
+	if mode == modeFloat {
+		a, err := matrixToFloat(matrix)
+		if err != nil {
+			return nil, err
+		}
+		res, err := powFloatMatrix(ctx, a, n, tol)
+		if err != nil {
+			return nil, err
+		}
+		return denseToMatrix(res, tol), nil
+	}
+
+	a, err := matrixToInt(ctx, matrix)
+	if err != nil {
+		return nil, err
+	}
+	res, err := powIntMatrix(ctx, a, n)
+	if err != nil {
+		return nil, err
+	}
+	return intToMatrix(res), nil
+}
+
+func (rout *Router) Power(w http.ResponseWriter, r *http.Request) {
+	matrix, err := rout.extractData(r, fileKey)
+	if err != nil {
+		rout.log.Error("extracting data from .csv file failed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n, err := strconv.Atoi(r.FormValue(exponentKey))
+	if err != nil {
+		http.Error(w, errInvalidExponent.Error(), http.StatusBadRequest)
+		return
+	}
+	mode := r.FormValue(modeKey)
+	switch {
+	case mode == "":
+		mode = modeInt
+	case mode != modeInt && mode != modeFloat:
+		http.Error(w, errInvalidMode.Error(), http.StatusBadRequest)
+		return
+	}
+	if n < 0 && mode == modeInt {
+		http.Error(w, errNegativeExponent.Error(), http.StatusBadRequest)
+		return
+	}
+	tol, _, err := parseNumericParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res, err := powerMatrix(r.Context(), matrix, n, mode, tol)
+	rout.log.Info("Power command called", zap.Int("n", n), zap.String("mode", mode))
+	if err != nil {
+		status := numericErrorStatus(err)
+		if errors.Is(err, errOverflow) || errors.Is(err, errSingularMatrix) {
+			status = http.StatusUnprocessableEntity
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	rout.writeMatrix(w, res)
+}
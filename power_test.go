@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_mulChecked(t *testing.T) {
+	tt := []struct {
+		name           string
+		providedA      int
+		providedB      int
+		expectedResult int
+		expectedOk     bool
+	}{
+		{
+			name:       "fail: overflow",
+			providedA:  math.MaxInt / 2,
+			providedB:  3,
+			expectedOk: false,
+		},
+		{
+			name:       "fail: min int negation",
+			providedA:  -1,
+			providedB:  math.MinInt,
+			expectedOk: false,
+		},
+		{
+			name:           "success: multiplied",
+			providedA:      -4,
+			providedB:      5,
+			expectedResult: -20,
+			expectedOk:     true,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, ok := mulChecked(tc.providedA, tc.providedB)
+			assert.Equal(t, tc.expectedResult, res)
+			assert.Equal(t, tc.expectedOk, ok)
+		})
+	}
+}
+
+func Test_powIntMatrix(t *testing.T) {
+	fibonacci := [][]int{{1, 1}, {1, 0}}
+
+	tt := []struct {
+		name           string
+		providedA      [][]int
+		providedN      int
+		expectedResult [][]int
+		expectedErr    error
+	}{
+		{
+			name:        "fail: negative exponent",
+			providedA:   fibonacci,
+			providedN:   -1,
+			expectedErr: errNegativeExponent,
+		},
+		{
+			name:        "fail: overflow",
+			providedA:   fibonacci,
+			providedN:   100,
+			expectedErr: errOverflow,
+		},
+		{
+			name:           "success: zero exponent",
+			providedA:      fibonacci,
+			providedN:      0,
+			expectedResult: [][]int{{1, 0}, {0, 1}},
+		},
+		{
+			name:           "success: fibonacci numbers",
+			providedA:      fibonacci,
+			providedN:      10,
+			expectedResult: [][]int{{89, 55}, {55, 34}},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := powIntMatrix(context.Background(), tc.providedA, tc.providedN)
+			assert.Equal(t, tc.expectedResult, res)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func Test_powFloatMatrix(t *testing.T) {
+	a := [][]float64{{4, 7}, {2, 6}}
+
+	tt := []struct {
+		name           string
+		providedA      [][]float64
+		providedN      int
+		expectedResult [][]float64
+		expectedErr    error
+	}{
+		{
+			name:        "fail: singular matrix with negative exponent",
+			providedA:   [][]float64{{1, 2}, {2, 4}},
+			providedN:   -1,
+			expectedErr: errSingularMatrix,
+		},
+		{
+			name:        "fail: overflow",
+			providedA:   [][]float64{{1e200, 0}, {0, 1}},
+			providedN:   2,
+			expectedErr: errOverflow,
+		},
+		{
+			name:           "success: inverse",
+			providedA:      a,
+			providedN:      -1,
+			expectedResult: [][]float64{{0.6, -0.7}, {-0.2, 0.4}},
+		},
+		{
+			name:           "success: square of inverse",
+			providedA:      a,
+			providedN:      -2,
+			expectedResult: [][]float64{{0.5, -0.7}, {-0.2, 0.3}},
+		},
+		{
+			name:           "success: cube",
+			providedA:      a,
+			providedN:      3,
+			expectedResult: [][]float64{{260, 630}, {180, 440}},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := powFloatMatrix(context.Background(), tc.providedA, tc.providedN, defaultTolerance)
+			assert.ErrorIs(t, err, tc.expectedErr)
+			if tc.expectedResult == nil {
+				assert.Nil(t, res)
+				return
+			}
+			assertDenseEqual(t, tc.expectedResult, res)
+		})
+	}
+}
+
+func TestRouter_Power(t *testing.T) {
+	router, err := setupRouter()
+	assert.NoError(t, err)
+
+	successReq, successW, err := createReq(validPath, testURL+"?n=2")
+	assert.NoError(t, err)
+	successReq.Header.Set("Content-Type", successW.FormDataContentType())
+
+	negativeReq, negativeW, err := createReq(validPath, testURL+"?n=-1")
+	assert.NoError(t, err)
+	negativeReq.Header.Set("Content-Type", negativeW.FormDataContentType())
+
+	singularReq, singularW, err := createReq(validPath, testURL+"?n=-1&mode=float")
+	assert.NoError(t, err)
+	singularReq.Header.Set("Content-Type", singularW.FormDataContentType())
+
+	overflowReq, overflowW, err := createReq(validPath, testURL+"?n=100")
+	assert.NoError(t, err)
+	overflowReq.Header.Set("Content-Type", overflowW.FormDataContentType())
+
+	tt := []struct {
+		name         string
+		providedReq  *http.Request
+		expectedBody string
+		expectedCode int
+	}{
+		{
+			name:         "fail: negative exponent in int mode - BadRequest",
+			providedReq:  negativeReq,
+			expectedBody: errNegativeExponent.Error() + "\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "fail: singular matrix - UnprocessableEntity",
+			providedReq:  singularReq,
+			expectedBody: errSingularMatrix.Error() + "\n",
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "fail: overflow - UnprocessableEntity",
+			providedReq:  overflowReq,
+			expectedBody: errOverflow.Error() + "\n",
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "success: power calculated",
+			providedReq:  successReq,
+			expectedBody: "30,36,42\n66,81,96\n102,126,150\n",
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.Power(w, tc.providedReq)
+
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	rowsKey = "rows"
+	colsKey = "cols"
+
+	rangeSep = ":"
+	listSep  = ","
+)
+
+var (
+	errElementCount    = errors.New("number of elements doesn't match")
+	errIndexOutOfRange = errors.New("index is out of range")
+	errInvalidRange    = errors.New("invalid range, should be \"start:end\" with 0 <= start < end")
+	errInvalidIndex    = errors.New("invalid index, should be non-negative integer")
+)
+
+// INFO: index range [start, end) of rows or columns.
+type indexRange struct {
+	start int
+	end   int
+}
+
+// INFO: parses "start:end" range, each side may be omitted (defaults are 0 and size), empty value means
+// the whole dimension.
+func parseRange(raw string, size int) (indexRange, error) {
+	if strings.TrimSpace(raw) == "" {
+		return indexRange{start: 0, end: size}, nil
+	}
+
+	startRaw, endRaw, ok := strings.Cut(raw, rangeSep)
+	if !ok {
+		return indexRange{}, errInvalidRange
+	}
+	res := indexRange{start: 0, end: size}
+	var err error
+	if strings.TrimSpace(startRaw) != "" {
+		res.start, err = strconv.Atoi(strings.TrimSpace(startRaw))
+		if err != nil {
+			return indexRange{}, errInvalidRange
+		}
+	}
+	if strings.TrimSpace(endRaw) != "" {
+		res.end, err = strconv.Atoi(strings.TrimSpace(endRaw))
+		if err != nil {
+			return indexRange{}, errInvalidRange
+		}
+	}
+
+	if res.start < 0 || res.start >= res.end {
+		return indexRange{}, errInvalidRange
+	}
+	if res.end > size {
+		return indexRange{}, fmt.Errorf("%w: end %d is greater than size %d", errIndexOutOfRange, res.end, size)
+	}
+	return res, nil
+}
+
+// INFO: parses comma separated list of indexes, empty value means all indexes of the dimension.
+func parseIndexes(raw string, size int) ([]int, error) {
+	if strings.TrimSpace(raw) == "" {
+		res := make([]int, size)
+		for i := range res {
+			res[i] = i
+		}
+		return res, nil
+	}
+
+	var res []int
+	for _, item := range strings.Split(raw, listSep) {
+		idx, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || idx < 0 {
+			return nil, errInvalidIndex
+		}
+		if idx >= size {
+			return nil, fmt.Errorf("%w: %d is not in [0, %d)", errIndexOutOfRange, idx, size)
+		}
+		res = append(res, idx)
+	}
+	return res, nil
+}
+
+// INFO: parses "RxC" shape, e.g. "2x8".
+func parseShape(raw string) (shape, error) {
+	rowsRaw, colsRaw, ok := strings.Cut(raw, "x")
+	rows, rowsErr := strconv.Atoi(rowsRaw)
+	cols, colsErr := strconv.Atoi(colsRaw)
+	if !ok || rowsErr != nil || colsErr != nil || rows <= 0 || cols <= 0 {
+		return shape{}, fmt.Errorf("%w: shape should be \"RxC\" with positive numbers, got %q", errInvalidParam, raw)
+	}
+	return shape{rows: rows, cols: cols}, nil
+}
+
+// INFO: checks that matrix with shape in can be reshaped to out. Number of elements is compared with division,
+// so huge requested dimensions can't overflow into a matching product.
+func checkReshape(in shape, out shape) error {
+	total := in.rows * in.cols
+	if total%out.cols != 0 || out.rows != total/out.cols {
+		return fmt.Errorf("%w: can't reshape %s to %s", errElementCount, in, out)
+	}
+	return nil
+}
+
+// INFO: rearranges elements in row-major order into the matrix with provided shape.
+func reshapeMatrix(matrix [][]string, out shape) ([][]string, error) {
+	err := checkReshape(matrixShape(matrix), out)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([][]string, out.rows)
+	for i := range res {
+		res[i] = make([]string, 0, out.cols)
+	}
+	k := 0
+	for _, row := range matrix {
+		for _, elem := range row {
+			res[k/out.cols] = append(res[k/out.cols], elem)
+			k++
+		}
+	}
+
+	return res, nil
+}
+
+// INFO: extracts submatrix with rows and columns from provided ranges.
+func sliceMatrix(matrix [][]string, rows indexRange, cols indexRange) [][]string {
+	res := make([][]string, 0, rows.end-rows.start)
+	for i := rows.start; i < rows.end; i++ {
+		row := make([]string, cols.end-cols.start)
+		copy(row, matrix[i][cols.start:cols.end])
+		res = append(res, row)
+	}
+	return res
+}
+
+// INFO: picks rows and columns by indexes in provided order, indexes may repeat.
+func selectMatrix(matrix [][]string, rows []int, cols []int) [][]string {
+	res := make([][]string, len(rows))
+	for i, r := range rows {
+		res[i] = make([]string, len(cols))
+		for j, c := range cols {
+			res[i][j] = matrix[r][c]
+		}
+	}
+	return res
+}
+
+func (rout *Router) Reshape(w http.ResponseWriter, r *http.Request) {
+	matrix, err := rout.parseMatrix(r, fileKey)
+	if err != nil {
+		rout.log.Error("extracting data from .csv file failed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	out, err := parseShape(r.FormValue(rowsKey) + "x" + r.FormValue(colsKey))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reshaped, err := reshapeMatrix(matrix, out)
+	rout.log.Info("Reshape command called", zap.Stringer("shape", out))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rout.writeMatrix(w, reshaped)
+}
+
+func (rout *Router) Slice(w http.ResponseWriter, r *http.Request) {
+	matrix, err := rout.parseMatrix(r, fileKey)
+	if err != nil {
+		rout.log.Error("extracting data from .csv file failed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	in := matrixShape(matrix)
+	rows, err := parseRange(r.FormValue(rowsKey), in.rows)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("rows: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	cols, err := parseRange(r.FormValue(colsKey), in.cols)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("cols: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	sliced := sliceMatrix(matrix, rows, cols)
+	rout.log.Info("Slice command called")
+	rout.writeMatrix(w, sliced)
+}
+
+func (rout *Router) Select(w http.ResponseWriter, r *http.Request) {
+	matrix, err := rout.parseMatrix(r, fileKey)
+	if err != nil {
+		rout.log.Error("extracting data from .csv file failed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	in := matrixShape(matrix)
+	rows, err := parseIndexes(r.FormValue(rowsKey), in.rows)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("rows: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	cols, err := parseIndexes(r.FormValue(colsKey), in.cols)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("cols: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	selected := selectMatrix(matrix, rows, cols)
+	rout.log.Info("Select command called")
+	rout.writeMatrix(w, selected)
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_parseRange(t *testing.T) {
+	tt := []struct {
+		name           string
+		providedRaw    string
+		expectedResult indexRange
+		expectedErr    error
+	}{
+		{
+			name:           "fail: no separator",
+			providedRaw:    "1",
+			expectedResult: indexRange{},
+			expectedErr:    errInvalidRange,
+		},
+		{
+			name:           "fail: empty range",
+			providedRaw:    "2:2",
+			expectedResult: indexRange{},
+			expectedErr:    errInvalidRange,
+		},
+		{
+			name:           "fail: end is out of range",
+			providedRaw:    "1:5",
+			expectedResult: indexRange{},
+			expectedErr:    errIndexOutOfRange,
+		},
+		{
+			name:           "success: whole dimension",
+			providedRaw:    "",
+			expectedResult: indexRange{start: 0, end: 3},
+			expectedErr:    nil,
+		},
+		{
+			name:           "success: open end",
+			providedRaw:    "1:",
+			expectedResult: indexRange{start: 1, end: 3},
+			expectedErr:    nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := parseRange(tc.providedRaw, 3)
+			assert.Equal(t, tc.expectedResult, res)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func Test_parseIndexes(t *testing.T) {
+	tt := []struct {
+		name           string
+		providedRaw    string
+		expectedResult []int
+		expectedErr    error
+	}{
+		{
+			name:           "fail: negative index",
+			providedRaw:    "0,-1",
+			expectedResult: nil,
+			expectedErr:    errInvalidIndex,
+		},
+		{
+			name:           "fail: index out of range",
+			providedRaw:    "0,3",
+			expectedResult: nil,
+			expectedErr:    errIndexOutOfRange,
+		},
+		{
+			name:           "success: all indexes",
+			providedRaw:    "",
+			expectedResult: []int{0, 1, 2},
+			expectedErr:    nil,
+		},
+		{
+			name:           "success: indexes parsed",
+			providedRaw:    "2, 0,2",
+			expectedResult: []int{2, 0, 2},
+			expectedErr:    nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := parseIndexes(tc.providedRaw, 3)
+			assert.Equal(t, tc.expectedResult, res)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func Test_reshapeMatrix(t *testing.T) {
+	matrix := [][]string{{"1", "2", "3"}, {"4", "5", "6"}}
+
+	tt := []struct {
+		name           string
+		providedShape  shape
+		expectedResult [][]string
+		expectedErr    error
+	}{
+		{
+			name:           "fail: element count mismatch",
+			providedShape:  shape{rows: 2, cols: 2},
+			expectedResult: nil,
+			expectedErr:    errElementCount,
+		},
+		{
+			name:           "fail: element count overflows",
+			providedShape:  shape{rows: 6148914691236517206, cols: 9},
+			expectedResult: nil,
+			expectedErr:    errElementCount,
+		},
+		{
+			name:           "success: reshaped to column",
+			providedShape:  shape{rows: 6, cols: 1},
+			expectedResult: [][]string{{"1"}, {"2"}, {"3"}, {"4"}, {"5"}, {"6"}},
+			expectedErr:    nil,
+		},
+		{
+			name:           "success: reshaped to 3x2",
+			providedShape:  shape{rows: 3, cols: 2},
+			expectedResult: [][]string{{"1", "2"}, {"3", "4"}, {"5", "6"}},
+			expectedErr:    nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := reshapeMatrix(matrix, tc.providedShape)
+			assert.Equal(t, tc.expectedResult, res)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func Test_sliceMatrix(t *testing.T) {
+	matrix := [][]string{{"1", "2", "3"}, {"4", "5", "6"}, {"7", "8", "9"}}
+
+	res := sliceMatrix(matrix, indexRange{start: 1, end: 3}, indexRange{start: 0, end: 2})
+	assert.Equal(t, [][]string{{"4", "5"}, {"7", "8"}}, res)
+}
+
+func Test_selectMatrix(t *testing.T) {
+	matrix := [][]string{{"1", "2", "3"}, {"4", "5", "6"}, {"7", "8", "9"}}
+
+	res := selectMatrix(matrix, []int{2, 0}, []int{1, 1})
+	assert.Equal(t, [][]string{{"8", "8"}, {"2", "2"}}, res)
+}
+
+func TestRouter_Reshape(t *testing.T) {
+	router, err := setupRouter()
+	assert.NoError(t, err)
+
+	successReq, successW, err := createReq(validPath, testURL+"?rows=1&cols=9")
+	assert.NoError(t, err)
+	successReq.Header.Set("Content-Type", successW.FormDataContentType())
+
+	countReq, countW, err := createReq(validPath, testURL+"?rows=2&cols=4")
+	assert.NoError(t, err)
+	countReq.Header.Set("Content-Type", countW.FormDataContentType())
+
+	overflowReq, overflowW, err := createReq(validPath, testURL+"?rows=3689348814741910325&cols=5")
+	assert.NoError(t, err)
+	overflowReq.Header.Set("Content-Type", overflowW.FormDataContentType())
+
+	notSquareReq, notSquareW, err := createReq(notSquarePath, testURL+"?rows=3&cols=2")
+	assert.NoError(t, err)
+	notSquareReq.Header.Set("Content-Type", notSquareW.FormDataContentType())
+
+	unsupportedReq, unsupportedW, err := createReq(unsupportedPath, testURL+"?rows=1&cols=9")
+	assert.NoError(t, err)
+	unsupportedReq.Header.Set("Content-Type", unsupportedW.FormDataContentType())
+
+	tt := []struct {
+		name         string
+		providedReq  *http.Request
+		expectedBody string
+		expectedCode int
+	}{
+		{
+			name:         "fail: extract data - BadRequest",
+			providedReq:  unsupportedReq,
+			expectedBody: errFileExtension.Error() + "\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "fail: element count mismatch - BadRequest",
+			providedReq:  countReq,
+			expectedBody: "number of elements doesn't match: can't reshape 3x3 to 2x4\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "fail: element count overflows - BadRequest",
+			providedReq:  overflowReq,
+			expectedBody: "number of elements doesn't match: can't reshape 3x3 to 3689348814741910325x5\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "success: reshaped",
+			providedReq:  successReq,
+			expectedBody: "1,2,3,4,5,6,7,8,9\n",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "success: not square matrix reshaped",
+			providedReq:  notSquareReq,
+			expectedBody: "1,2\n3,4\n5,6\n",
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.Reshape(w, tc.providedReq)
+
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestRouter_Slice(t *testing.T) {
+	router, err := setupRouter()
+	assert.NoError(t, err)
+
+	successReq, successW, err := createReq(validPath, testURL+"?rows=0:2&cols=1:")
+	assert.NoError(t, err)
+	successReq.Header.Set("Content-Type", successW.FormDataContentType())
+
+	notSquareReq, notSquareW, err := createReq(notSquarePath, testURL+"?rows=1:&cols=:2")
+	assert.NoError(t, err)
+	notSquareReq.Header.Set("Content-Type", notSquareW.FormDataContentType())
+
+	outOfRangeReq, outOfRangeW, err := createReq(validPath, testURL+"?cols=1:4")
+	assert.NoError(t, err)
+	outOfRangeReq.Header.Set("Content-Type", outOfRangeW.FormDataContentType())
+
+	tt := []struct {
+		name         string
+		providedReq  *http.Request
+		expectedBody string
+		expectedCode int
+	}{
+		{
+			name:         "fail: out of range - BadRequest",
+			providedReq:  outOfRangeReq,
+			expectedBody: "cols: index is out of range: end 4 is greater than size 3\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "success: sliced",
+			providedReq:  successReq,
+			expectedBody: "2,3\n5,6\n",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "success: not square matrix sliced",
+			providedReq:  notSquareReq,
+			expectedBody: "4,5\n",
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.Slice(w, tc.providedReq)
+
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestRouter_Select(t *testing.T) {
+	router, err := setupRouter()
+	assert.NoError(t, err)
+
+	successReq, successW, err := createReq(validPath, testURL+"?rows=2,0")
+	assert.NoError(t, err)
+	successReq.Header.Set("Content-Type", successW.FormDataContentType())
+
+	notSquareReq, notSquareW, err := createReq(notSquarePath, testURL+"?rows=1,0")
+	assert.NoError(t, err)
+	notSquareReq.Header.Set("Content-Type", notSquareW.FormDataContentType())
+
+	outOfRangeReq, outOfRangeW, err := createReq(validPath, testURL+"?rows=3")
+	assert.NoError(t, err)
+	outOfRangeReq.Header.Set("Content-Type", outOfRangeW.FormDataContentType())
+
+	tt := []struct {
+		name         string
+		providedReq  *http.Request
+		expectedBody string
+		expectedCode int
+	}{
+		{
+			name:         "fail: out of range - BadRequest",
+			providedReq:  outOfRangeReq,
+			expectedBody: "rows: index is out of range: 3 is not in [0, 3)\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "success: selected",
+			providedReq:  successReq,
+			expectedBody: "7,8,9\n1,2,3\n",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "success: not square matrix selected",
+			providedReq:  notSquareReq,
+			expectedBody: "4,5,6\n1,2,3\n",
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.Select(w, tc.providedReq)
+
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
@@ -1,54 +1,345 @@
 package main
 
 import (
-	"encoding/csv"
+	"crypto/rsa"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"go.uber.org/zap"
 	http "net/http"
-	"path/filepath"
+	"runtime"
+	"time"
 )
 
 const (
 	// end-points paths
-	echo     = "/echo"
-	invert   = "/invert"
-	flatten  = "/flatten"
-	sum      = "/sum"
-	multiply = "/multiply"
-
-	csvExt  = ".csv"
-	fileKey = "file"
+	echo          = "/echo"
+	invert        = "/invert"
+	flatten       = "/flatten"
+	sum           = "/sum"
+	multiply      = "/multiply"
+	batch         = "/batch"
+	pipeline      = "/pipeline"
+	stats         = "/stats"
+	mapping       = "/map"
+	reshape       = "/reshape"
+	slice         = "/slice"
+	choose        = "/select"
+	rotate        = "/rotate"
+	flip          = "/flip"
+	antiTranspose = "/antitranspose"
+	diagonal      = "/diagonal"
+	triangle      = "/triangle"
+	decompose     = "/decompose"
+	solve         = "/solve"
+	power         = "/power"
+
+	csvExt    = ".csv"
+	fileKey   = "file"
+	axisKey   = "axis"
+	formatKey = "format"
+	orderKey  = "order"
+	layoutKey = "layout"
+	sepKey    = "sep"
+
+	// INFO: key of limiters applied to routes without their own ones.
+	allRoutes = ""
 )
 
 var (
-	errFileExtension   = errors.New("invalid file extension, should be \"*.csv\"")
+	errFileExtension   = errors.New("invalid file extension, should be one of \"*.csv\", \"*.tsv\", \"*.txt\", \"*.mtx\", \"*.xlsx\"")
 	errEmptyFile       = errors.New("there are no data in file")
 	errMatrixNotSquare = errors.New("matrix should be square, number of rows are equal to the number of columns")
 )
 
+// INFO: middleware wraps handler with additional behaviour, e.g. compression.
+type middleware func(next http.Handler) http.Handler
+
 type Router struct {
 	*http.ServeMux
-	log *zap.Logger
+	log         *zap.Logger
+	middlewares []middleware
+	jobs        *jobManager
+	matrices    MatrixStore
+	matrixTTL   time.Duration
+	cache       *resultCache
+	// INFO: limiters by route pattern, allRoutes key is used for routes without their own limiter.
+	rateLimits        map[string]*rateLimiter
+	concurrencyLimits map[string]middleware
+	// INFO: all routes require credentials when authenticator is configured.
+	auth *authenticator
+	cors *cors
+	// INFO: patterns in order of registration, used to describe the API.
+	routes []string
+	// INFO: parallelism of row-block computations of every request.
+	parallelism parallelism
+	// INFO: upper bound of decompressed request body or file.
+	maxExpandedSize int64
+	// INFO: errors of invalid options, they are returned by NewRouter.
+	errs []error
+}
+
+// INFO: Option configures Router in NewRouter, defaults are used for everything not configured.
+type Option func(rout *Router)
+
+// INFO: replaces default in-memory storage of async jobs.
+func WithJobStore(store JobStore) Option {
+	return func(rout *Router) {
+		rout.jobs.store = store
+	}
+}
+
+// INFO: sets number of async job workers and size of the queue of jobs waiting for a worker.
+func WithJobWorkers(workers int, queueSize int) Option {
+	return func(rout *Router) {
+		rout.jobs.workers = workers
+		rout.jobs.queueSize = queueSize
+	}
+}
+
+// INFO: replaces default in-memory storage of uploaded matrices.
+func WithMatrixStore(store MatrixStore) Option {
+	return func(rout *Router) {
+		rout.matrices = store
+	}
+}
+
+// INFO: sets default and maximal lifetime of stored matrices.
+func WithMatrixTTL(ttl time.Duration) Option {
+	return func(rout *Router) {
+		rout.matrixTTL = ttl
+	}
+}
+
+// INFO: enables cache of operation results limited by number of entries and total size of bodies.
+func WithResultCache(maxEntries int, maxBytes int64, ttl time.Duration) Option {
+	return func(rout *Router) {
+		rout.cache = newResultCache(maxEntries, maxBytes, ttl)
+	}
+}
+
+// INFO: sets number of goroutines used by matrix computations of every request, GOMAXPROCS by default.
+func WithWorkers(n int) Option {
+	return func(rout *Router) {
+		if n > 0 {
+			rout.parallelism.workers = n
+		}
+	}
+}
+
+// INFO: matrices with fewer cells are computed serially, 16384 cells by default.
+func WithParallelThreshold(cells int) Option {
+	return func(rout *Router) {
+		if cells > 0 {
+			rout.parallelism.threshold = cells
+		}
+	}
+}
+
+// INFO: limits size of decompressed request body and of every uploaded file, 64MB by default. Archive formats
+// (.zip uploads of multi requests, .xlsx) share the limit between their parts.
+func WithMaxExpandedSize(size int64) Option {
+	return func(rout *Router) {
+		if size > 0 {
+			rout.maxExpandedSize = size
+		}
+	}
 }
 
-func NewRouter(log *zap.Logger) *Router {
-	return &Router{
-		ServeMux: http.NewServeMux(),
-		log:      log,
+// INFO: limits number of operations computed at the same time, extra requests wait up to timeout in queue of
+// maxQueued size. Listed routes share the limit, all operation routes share it when none is listed.
+func WithConcurrencyLimit(maxActive int, maxQueued int, timeout time.Duration, patterns ...string) Option {
+	return func(rout *Router) {
+		setLimit(rout.concurrencyLimits, newConcurrencyLimiter(maxActive, maxQueued, timeout).middleware, patterns)
+	}
+}
+
+// INFO: limits requests of every client to rate per second with bursts up to burst requests. Listed routes
+// share budget of a client, all routes share it when none is listed.
+func WithRateLimit(rate float64, burst int, patterns ...string) Option {
+	return func(rout *Router) {
+		setLimit(rout.rateLimits, newRateLimiter(rate, burst), patterns)
+	}
+}
+
+// INFO: enables authentication with static API keys.
+func WithAPIKeys(keys ...APIKey) Option {
+	return func(rout *Router) {
+		rout.authenticator().addAPIKeys(keys)
+	}
+}
+
+// INFO: enables authentication with JWT bearer tokens signed by HMAC secret (HS256).
+func WithJWTSecret(secret []byte) Option {
+	return func(rout *Router) {
+		rout.authenticator().hmacSecret = secret
+	}
+}
+
+// INFO: enables authentication with JWT bearer tokens signed by RSA keys (RS256) identified by "kid".
+func WithJWKS(keys map[string]*rsa.PublicKey) Option {
+	return func(rout *Router) {
+		for kid, key := range keys {
+			rout.authenticator().rsaKeys[kid] = key
+		}
+	}
+}
+
+// INFO: allows cross-origin requests from browsers. NewRouter fails when credentials are allowed for any
+// origin "*".
+func WithCORS(cfg CORSConfig) Option {
+	return func(rout *Router) {
+		c, err := newCORS(cfg)
+		if err != nil {
+			rout.errs = append(rout.errs, err)
+			return
+		}
+		rout.cors = c
+	}
+}
+
+func (rout *Router) authenticator() *authenticator {
+	if rout.auth == nil {
+		rout.auth = newAuthenticator()
+	}
+	return rout.auth
+}
+
+func setLimit[T any](limits map[string]T, limit T, patterns []string) {
+	if len(patterns) == 0 {
+		patterns = []string{allRoutes}
+	}
+	for _, pattern := range patterns {
+		limits[pattern] = limit
+	}
+}
+
+// INFO: returns errors of all invalid options joined, background workers aren't started then.
+func NewRouter(log *zap.Logger, opts ...Option) (*Router, error) {
+	mux := http.NewServeMux()
+	rout := &Router{
+		ServeMux:        mux,
+		log:             log,
+		jobs:            newJobManager(mux),
+		matrices:        NewMemoryMatrixStore(MatrixQuota{MaxBytes: defaultMatrixMaxBytes, MaxMatrices: defaultMatrixMaxMatrices}),
+		matrixTTL:       defaultMatrixTTL,
+		parallelism:     parallelism{workers: runtime.GOMAXPROCS(0), threshold: defaultParallelThreshold},
+		maxExpandedSize: defaultMaxExpandedSize,
+
+		rateLimits:        map[string]*rateLimiter{},
+		concurrencyLimits: map[string]middleware{},
+	}
+	rout.middlewares = []middleware{compressResponse, rout.decompressRequest}
+	for _, opt := range opts {
+		opt(rout)
+	}
+	if err := errors.Join(rout.errs...); err != nil {
+		return nil, err
+	}
+	rout.jobs.start()
+	return rout, nil
+}
+
+// INFO: stops background workers, unfinished async jobs are canceled.
+func (rout *Router) Close() {
+	rout.jobs.stop()
+}
+
+// INFO: registers handler wrapped with router middlewares, the first middleware is the outermost one.
+func (rout *Router) handle(pattern string, h http.HandlerFunc) {
+	rout.register(pattern, h, false)
+}
+
+// INFO: registers operation handler, its results are served from the cache when it is enabled.
+func (rout *Router) handleCached(pattern string, h http.HandlerFunc) {
+	var handler http.Handler = h
+	if rout.cache != nil {
+		handler = rout.cache.middleware(handler)
+	}
+	rout.register(pattern, handler, true)
+}
+
+// INFO: CORS is the outermost layer, so preflight requests are answered without credentials and errors of
+// other layers are readable by browsers. Authentication goes next, so rate limit is applied to the verified
+// client, failed authentication attempts are limited by client IP with the same limiter. Concurrency limit wraps
+// only the computation itself, global concurrency limit applies to operation routes.
+func (rout *Router) register(pattern string, handler http.Handler, operation bool) {
+	handler = rout.computeParallelism(handler)
+	if limit := limitFor(rout.concurrencyLimits, pattern, operation); limit != nil {
+		handler = limit(handler)
+	}
+	for i := len(rout.middlewares) - 1; i >= 0; i-- {
+		handler = rout.middlewares[i](handler)
+	}
+	rateLimit := limitFor(rout.rateLimits, pattern, true)
+	if rateLimit != nil {
+		handler = rateLimit.middleware(handler)
+	}
+	if rout.auth != nil && !publicRoutes[pattern] {
+		handler = rout.auth.middleware(routeOperation(pattern), rateLimit, rout.log)(handler)
+	}
+	if rout.cors != nil {
+		handler = rout.cors.middleware(handler)
 	}
+	rout.Handle(pattern, handler)
+	rout.routes = append(rout.routes, pattern)
+}
+
+// INFO: passes parallelism of the router to computations of the request.
+func (rout *Router) computeParallelism(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(withParallelism(r.Context(), rout.parallelism)))
+	})
+}
+
+func limitFor[T any](limits map[string]T, pattern string, useDefault bool) T {
+	if limit, ok := limits[pattern]; ok {
+		return limit
+	}
+	if useDefault {
+		return limits[allRoutes]
+	}
+	var none T
+	return none
 }
 
 func (rout *Router) InitRoutes() {
-	rout.HandleFunc(echo, rout.Echo)
-	rout.HandleFunc(invert, rout.Invert)
-	rout.HandleFunc(flatten, rout.Flatten)
-	rout.HandleFunc(sum, rout.Sum)
-	rout.HandleFunc(multiply, rout.Multiply)
+	rout.handleCached(echo, rout.Echo)
+	rout.handleCached(invert, rout.Invert)
+	rout.handleCached(flatten, rout.Flatten)
+	rout.handleCached(sum, rout.Sum)
+	rout.handleCached(multiply, rout.Multiply)
+	rout.handleCached(batch, rout.Batch)
+	rout.handleCached(pipeline, rout.Pipeline)
+	rout.handleCached(stats, rout.Stats)
+	rout.handleCached(mapping, rout.Map)
+	rout.handleCached(reshape, rout.Reshape)
+	rout.handleCached(slice, rout.Slice)
+	rout.handleCached(choose, rout.Select)
+	rout.handleCached(rotate, rout.Rotate)
+	rout.handleCached(flip, rout.Flip)
+	rout.handleCached(antiTranspose, rout.AntiTranspose)
+	rout.handleCached(diagonal, rout.Diagonal)
+	rout.handleCached(triangle, rout.Triangle)
+	rout.handleCached(decompose, rout.Decompose)
+	rout.handleCached(solve, rout.Solve)
+	rout.handleCached(power, rout.Power)
+	rout.handleCached(multi, rout.Multi)
+	rout.handleCached(sparseTranspose, rout.SparseTranspose)
+	rout.handleCached(sparseSum, rout.SparseSum)
+	rout.handleCached(sparseMultiply, rout.SparseMultiply)
+	rout.handleCached(sparseMatmul, rout.SparseMatmul)
+	rout.handleCached(sparseStats, rout.SparseStats)
+	rout.handle(jobs, rout.SubmitJob)
+	rout.handle(jobsPrefix, rout.Job)
+	rout.handle(matrices, rout.StoreMatrix)
+	rout.handle(matricesPrefix, rout.Matrix)
+	rout.handle(cacheStats, rout.CacheStats)
+	rout.handle(openAPIPath, rout.OpenAPI)
 }
 
 func (rout *Router) Echo(w http.ResponseWriter, r *http.Request) {
-	matrix, err := extractData(r, fileKey)
+	matrix, err := rout.extractData(r, fileKey)
 	if err != nil {
 		rout.log.Error("extracting data from .csv file failed", zap.Error(err))
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -67,15 +358,20 @@ func (rout *Router) Echo(w http.ResponseWriter, r *http.Request) {
 }
 
 func (rout *Router) Invert(w http.ResponseWriter, r *http.Request) {
-	matrix, err := extractData(r, fileKey)
+	matrix, err := rout.extractData(r, fileKey)
 	if err != nil {
 		rout.log.Error("extracting data from .csv file failed", zap.Error(err))
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	inverted := invertMatrix(matrix)
-	response := convertToMatrixString(inverted)
+	// INFO: matrix is parsed for this request only and is square, so it is transposed without a copy.
+	err = transposeInPlace(r.Context(), matrix)
+	if err != nil {
+		http.Error(w, err.Error(), numericErrorStatus(err))
+		return
+	}
+	response := convertToMatrixString(matrix)
 	rout.log.Info("Invert command called")
 	w.WriteHeader(http.StatusOK)
 
@@ -87,15 +383,28 @@ func (rout *Router) Invert(w http.ResponseWriter, r *http.Request) {
 }
 
 func (rout *Router) Flatten(w http.ResponseWriter, r *http.Request) {
-	matrix, err := extractData(r, fileKey)
+	matrix, err := rout.extractData(r, fileKey)
 	if err != nil {
 		rout.log.Error("extracting data from .csv file failed", zap.Error(err))
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	response := convertToPlainString(matrix)
-	rout.log.Info("Flatten command called")
+	items, err := flattenMatrix(matrix, r.FormValue(orderKey))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sep := defaultSep
+	if values, ok := r.Form[sepKey]; ok {
+		sep = values[0]
+	}
+	response, err := joinFlat(items, r.FormValue(layoutKey), sep)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rout.log.Info("Flatten command called", zap.String("order", r.FormValue(orderKey)))
 	w.WriteHeader(http.StatusOK)
 
 	_, err = fmt.Fprint(w, response)
@@ -106,20 +415,34 @@ func (rout *Router) Flatten(w http.ResponseWriter, r *http.Request) {
 }
 
 func (rout *Router) Sum(w http.ResponseWriter, r *http.Request) {
-	matrix, err := extractData(r, fileKey)
+	matrix, err := rout.extractData(r, fileKey)
 	if err != nil {
 		rout.log.Error("extracting data from .csv file failed", zap.Error(err))
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	res, err := sumMatrix(matrix)
-	rout.log.Info("Sum command called")
+	axis, err := parseAxis(r.FormValue(axisKey))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format, err := parseFormat(r.FormValue(formatKey))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res, err := sumAxis(r.Context(), matrix, axis)
+	rout.log.Info("Sum command called", zap.String("axis", axis))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	response := fmt.Sprintf("%d\n", res)
+	response := formatVector(res, axis, format)
+	if format == formatJSON {
+		w.Header().Set("Content-Type", "application/json")
+	}
 	w.WriteHeader(http.StatusOK)
 
 	_, err = fmt.Fprint(w, response)
@@ -130,20 +453,34 @@ func (rout *Router) Sum(w http.ResponseWriter, r *http.Request) {
 }
 
 func (rout *Router) Multiply(w http.ResponseWriter, r *http.Request) {
-	matrix, err := extractData(r, fileKey)
+	matrix, err := rout.extractData(r, fileKey)
 	if err != nil {
 		rout.log.Error("extracting data from .csv file failed", zap.Error(err))
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	res, err := multiplyMatrix(matrix)
-	rout.log.Info("Multiply command called")
+	axis, err := parseAxis(r.FormValue(axisKey))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format, err := parseFormat(r.FormValue(formatKey))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res, err := multiplyAxis(r.Context(), matrix, axis)
+	rout.log.Info("Multiply command called", zap.String("axis", axis))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	response := fmt.Sprintf("%d\n", res)
+	response := formatVector(res, axis, format)
+	if format == formatJSON {
+		w.Header().Set("Content-Type", "application/json")
+	}
 	w.WriteHeader(http.StatusOK)
 
 	_, err = fmt.Fprint(w, response)
@@ -153,7 +490,53 @@ func (rout *Router) Multiply(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func extractData(r *http.Request, key string) ([][]string, error) {
+// INFO: writes matrix in matrix view with the same format as Echo response.
+func (rout *Router) writeMatrix(w http.ResponseWriter, matrix [][]string) {
+	w.WriteHeader(http.StatusOK)
+
+	_, err := fmt.Fprint(w, convertToMatrixString(matrix))
+	if err != nil {
+		rout.log.Error("writing response failed", zap.Error(err))
+	}
+}
+
+// INFO: writes value as JSON body with provided status code.
+func writeJSON(w http.ResponseWriter, status int, v any) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (rout *Router) extractData(r *http.Request, key string) ([][]string, error) {
+	matrix, err := rout.parseMatrix(r, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isSquare(matrix) {
+		return nil, errMatrixNotSquare
+	}
+
+	return matrix, nil
+}
+
+// INFO: options of decoders for the request.
+func (rout *Router) decodeOptions(r *http.Request) decodeOptions {
+	return decodeOptions{sheet: r.FormValue(sheetKey), maxExpandedSize: rout.maxExpandedSize}
+}
+
+// INFO: reads matrix from uploaded file without squareness check. Decoder is chosen by file extension or
+// content type of the part, all of them guarantee that rows have the same number of columns. Matrix stored
+// on the server is used instead of the file when its id is provided, see matrixIDParam.
+func (rout *Router) parseMatrix(r *http.Request, key string) ([][]string, error) {
+	if id := r.FormValue(matrixIDParam(key)); id != "" {
+		m, err := rout.matrices.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		return m.Data, nil
+	}
+
 	file, header, err := r.FormFile(key)
 	if err != nil {
 		return nil, err
@@ -162,12 +545,16 @@ func extractData(r *http.Request, key string) ([][]string, error) {
 		err = file.Close()
 	}()
 
-	extension := filepath.Ext(header.Filename)
-	if extension != csvExt {
-		return nil, errFileExtension
+	content, filename, err := decompressFile(file, header.Filename, rout.maxExpandedSize)
+	if err != nil {
+		return nil, err
+	}
+	decode, err := lookupDecoder(filename, header.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
 	}
 
-	matrix, err := csv.NewReader(file).ReadAll()
+	matrix, err := decode(content, rout.decodeOptions(r))
 	if err != nil {
 		return nil, err
 	}
@@ -176,9 +563,5 @@ func extractData(r *http.Request, key string) ([][]string, error) {
 		return nil, errEmptyFile
 	}
 
-	if !isSquare(matrix) {
-		return nil, errMatrixNotSquare
-	}
-
 	return matrix, err
 }
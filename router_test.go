@@ -13,21 +13,29 @@ import (
 )
 
 const (
-	validPath     = "./data/matrix.csv"
-	txtPath       = "./data/text.txt"
-	emptyPath     = "./data/empty.csv"
-	notSquarePath = "./data/notSquare.csv"
+	validPath       = "./data/matrix.csv"
+	txtPath         = "./data/text.txt"
+	tsvPath         = "./data/matrix.tsv"
+	upperPath       = "./data/upper.CSV"
+	xlsxPath        = "./data/matrix.xlsx"
+	unsupportedPath = "./data/matrix.dat"
+	emptyPath       = "./data/empty.csv"
+	notSquarePath   = "./data/notSquare.csv"
+	notNumberPath   = "./data/notNumber.csv"
 
 	testURL = "http://localhost:3000"
 )
 
-func setupRouter() (*Router, error) {
+func setupRouter(opts ...Option) (*Router, error) {
 	logger, err := zap.NewProduction()
 	if err != nil {
 		return nil, err
 	}
 
-	router := NewRouter(logger)
+	router, err := NewRouter(logger, opts...)
+	if err != nil {
+		return nil, err
+	}
 	router.InitRoutes()
 	return router, nil
 }
@@ -67,6 +75,55 @@ func createReq(filePath string, url string) (*http.Request, *multipart.Writer, e
 	return req, writer, nil
 }
 
+type formFile struct {
+	key  string
+	path string
+}
+
+// INFO: creates multipart request with several files, Content-Type header is already set.
+func createFilesReq(url string, files ...formFile) (*http.Request, error) {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	for _, f := range files {
+		formFile, err := writer.CreateFormFile(f.key, f.path)
+		if err != nil {
+			return nil, err
+		}
+		content, err := os.ReadFile(f.path)
+		if err != nil {
+			return nil, err
+		}
+		_, err = formFile.Write(content)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := writer.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	return req, nil
+}
+
+// INFO: writes single form file with provided name and content into body.
+func multipartWriterWithFile(t *testing.T, body io.Writer, filename string, content []byte) *multipart.Writer {
+	t.Helper()
+	writer := multipart.NewWriter(body)
+	formFile, err := writer.CreateFormFile(fileKey, filename)
+	assert.NoError(t, err)
+	_, err = formFile.Write(content)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+	return writer
+}
+
 func TestRouter_Echo(t *testing.T) {
 	validBody := "1,2,3\n4,5,6\n7,8,9\n"
 	router, err := setupRouter()
@@ -76,9 +133,9 @@ func TestRouter_Echo(t *testing.T) {
 	assert.NoError(t, err)
 	successReq.Header.Set("Content-Type", successW.FormDataContentType())
 
-	txtReq, txtW, err := createReq(txtPath, testURL)
+	unsupportedReq, unsupportedW, err := createReq(unsupportedPath, testURL)
 	assert.NoError(t, err)
-	txtReq.Header.Set("Content-Type", txtW.FormDataContentType())
+	unsupportedReq.Header.Set("Content-Type", unsupportedW.FormDataContentType())
 
 	tt := []struct {
 		name         string
@@ -88,8 +145,8 @@ func TestRouter_Echo(t *testing.T) {
 	}{
 		{
 			name:         "fail: extract data - BadRequest",
-			providedReq:  txtReq,
-			expectedBody: "invalid file extension, should be \"*.csv\"\n",
+			providedReq:  unsupportedReq,
+			expectedBody: errFileExtension.Error() + "\n",
 			expectedCode: http.StatusBadRequest,
 		},
 		{
@@ -122,9 +179,9 @@ func TestRouter_Invert(t *testing.T) {
 	assert.NoError(t, err)
 	successReq.Header.Set("Content-Type", successW.FormDataContentType())
 
-	txtReq, txtW, err := createReq(txtPath, testURL)
+	unsupportedReq, unsupportedW, err := createReq(unsupportedPath, testURL)
 	assert.NoError(t, err)
-	txtReq.Header.Set("Content-Type", txtW.FormDataContentType())
+	unsupportedReq.Header.Set("Content-Type", unsupportedW.FormDataContentType())
 
 	tt := []struct {
 		name         string
@@ -134,8 +191,8 @@ func TestRouter_Invert(t *testing.T) {
 	}{
 		{
 			name:         "fail: extract data - BadRequest",
-			providedReq:  txtReq,
-			expectedBody: "invalid file extension, should be \"*.csv\"\n",
+			providedReq:  unsupportedReq,
+			expectedBody: errFileExtension.Error() + "\n",
 			expectedCode: http.StatusBadRequest,
 		},
 		{
@@ -168,9 +225,21 @@ func TestRouter_Flatten(t *testing.T) {
 	assert.NoError(t, err)
 	successReq.Header.Set("Content-Type", successW.FormDataContentType())
 
-	txtReq, txtW, err := createReq(txtPath, testURL)
+	unsupportedReq, unsupportedW, err := createReq(unsupportedPath, testURL)
 	assert.NoError(t, err)
-	txtReq.Header.Set("Content-Type", txtW.FormDataContentType())
+	unsupportedReq.Header.Set("Content-Type", unsupportedW.FormDataContentType())
+
+	columnReq, columnW, err := createReq(validPath, testURL+"?order=column&sep=%3B")
+	assert.NoError(t, err)
+	columnReq.Header.Set("Content-Type", columnW.FormDataContentType())
+
+	snakeReq, snakeW, err := createReq(validPath, testURL+"?order=snake&layout=column")
+	assert.NoError(t, err)
+	snakeReq.Header.Set("Content-Type", snakeW.FormDataContentType())
+
+	invalidOrderReq, invalidOrderW, err := createReq(validPath, testURL+"?order=spiral")
+	assert.NoError(t, err)
+	invalidOrderReq.Header.Set("Content-Type", invalidOrderW.FormDataContentType())
 
 	tt := []struct {
 		name         string
@@ -180,8 +249,14 @@ func TestRouter_Flatten(t *testing.T) {
 	}{
 		{
 			name:         "fail: extract data - BadRequest",
-			providedReq:  txtReq,
-			expectedBody: "invalid file extension, should be \"*.csv\"\n",
+			providedReq:  unsupportedReq,
+			expectedBody: errFileExtension.Error() + "\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "fail: invalid order - BadRequest",
+			providedReq:  invalidOrderReq,
+			expectedBody: errInvalidOrder.Error() + "\n",
 			expectedCode: http.StatusBadRequest,
 		},
 		{
@@ -190,6 +265,18 @@ func TestRouter_Flatten(t *testing.T) {
 			expectedBody: validBody,
 			expectedCode: http.StatusOK,
 		},
+		{
+			name:         "success: flatten in column-major order with separator",
+			providedReq:  columnReq,
+			expectedBody: "1;4;7;2;5;8;3;6;9\n",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "success: flatten in snake order as single column",
+			providedReq:  snakeReq,
+			expectedBody: "1\n2\n3\n6\n5\n4\n7\n8\n9\n",
+			expectedCode: http.StatusOK,
+		},
 	}
 
 	for _, tc := range tt {
@@ -213,9 +300,17 @@ func TestRouter_Sum(t *testing.T) {
 	assert.NoError(t, err)
 	successReq.Header.Set("Content-Type", successW.FormDataContentType())
 
-	txtReq, txtW, err := createReq(txtPath, testURL)
+	unsupportedReq, unsupportedW, err := createReq(unsupportedPath, testURL)
 	assert.NoError(t, err)
-	txtReq.Header.Set("Content-Type", txtW.FormDataContentType())
+	unsupportedReq.Header.Set("Content-Type", unsupportedW.FormDataContentType())
+
+	rowsReq, rowsW, err := createReq(validPath, testURL+"?axis=rows&format=json")
+	assert.NoError(t, err)
+	rowsReq.Header.Set("Content-Type", rowsW.FormDataContentType())
+
+	invalidAxisReq, invalidAxisW, err := createReq(validPath, testURL+"?axis=diagonal")
+	assert.NoError(t, err)
+	invalidAxisReq.Header.Set("Content-Type", invalidAxisW.FormDataContentType())
 
 	tt := []struct {
 		name         string
@@ -225,8 +320,14 @@ func TestRouter_Sum(t *testing.T) {
 	}{
 		{
 			name:         "fail: extract data - BadRequest",
-			providedReq:  txtReq,
-			expectedBody: "invalid file extension, should be \"*.csv\"\n",
+			providedReq:  unsupportedReq,
+			expectedBody: errFileExtension.Error() + "\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "fail: invalid axis - BadRequest",
+			providedReq:  invalidAxisReq,
+			expectedBody: errInvalidAxis.Error() + "\n",
 			expectedCode: http.StatusBadRequest,
 		},
 		{
@@ -235,6 +336,12 @@ func TestRouter_Sum(t *testing.T) {
 			expectedBody: "45\n",
 			expectedCode: http.StatusOK,
 		},
+		{
+			name:         "success: sum per row calculated",
+			providedReq:  rowsReq,
+			expectedBody: "[6,15,24]\n",
+			expectedCode: http.StatusOK,
+		},
 	}
 
 	for _, tc := range tt {
@@ -258,9 +365,13 @@ func TestRouter_Multiply(t *testing.T) {
 	assert.NoError(t, err)
 	successReq.Header.Set("Content-Type", successW.FormDataContentType())
 
-	txtReq, txtW, err := createReq(txtPath, testURL)
+	unsupportedReq, unsupportedW, err := createReq(unsupportedPath, testURL)
+	assert.NoError(t, err)
+	unsupportedReq.Header.Set("Content-Type", unsupportedW.FormDataContentType())
+
+	colsReq, colsW, err := createReq(validPath, testURL+"?axis=cols")
 	assert.NoError(t, err)
-	txtReq.Header.Set("Content-Type", txtW.FormDataContentType())
+	colsReq.Header.Set("Content-Type", colsW.FormDataContentType())
 
 	tt := []struct {
 		name         string
@@ -270,8 +381,8 @@ func TestRouter_Multiply(t *testing.T) {
 	}{
 		{
 			name:         "fail: extract data - BadRequest",
-			providedReq:  txtReq,
-			expectedBody: "invalid file extension, should be \"*.csv\"\n",
+			providedReq:  unsupportedReq,
+			expectedBody: errFileExtension.Error() + "\n",
 			expectedCode: http.StatusBadRequest,
 		},
 		{
@@ -280,6 +391,12 @@ func TestRouter_Multiply(t *testing.T) {
 			expectedBody: "362880\n",
 			expectedCode: http.StatusOK,
 		},
+		{
+			name:         "success: product per column calculated",
+			providedReq:  colsReq,
+			expectedBody: "28,80,162\n",
+			expectedCode: http.StatusOK,
+		},
 	}
 
 	for _, tc := range tt {
@@ -296,13 +413,28 @@ func TestRouter_Multiply(t *testing.T) {
 }
 
 func Test_extractData(t *testing.T) {
+	router, err := setupRouter()
+	assert.NoError(t, err)
+
 	successReq, successW, err := createReq(validPath, testURL)
 	assert.NoError(t, err)
 	successReq.Header.Set("Content-Type", successW.FormDataContentType())
 
-	txtReq, txtW, err := createReq(txtPath, testURL)
+	unsupportedReq, unsupportedW, err := createReq(unsupportedPath, testURL)
+	assert.NoError(t, err)
+	unsupportedReq.Header.Set("Content-Type", unsupportedW.FormDataContentType())
+
+	txtReq, err := createFilesReq(testURL, formFile{key: fileKey, path: txtPath})
+	assert.NoError(t, err)
+
+	tsvReq, err := createFilesReq(testURL, formFile{key: fileKey, path: tsvPath})
+	assert.NoError(t, err)
+
+	upperReq, err := createFilesReq(testURL, formFile{key: fileKey, path: upperPath})
+	assert.NoError(t, err)
+
+	xlsxReq, err := createFilesReq(testURL, formFile{key: fileKey, path: xlsxPath})
 	assert.NoError(t, err)
-	txtReq.Header.Set("Content-Type", txtW.FormDataContentType())
 
 	emptyReq, emptyW, err := createReq(emptyPath, testURL)
 	assert.NoError(t, err)
@@ -321,7 +453,7 @@ func Test_extractData(t *testing.T) {
 
 		{
 			name:           "fail: wrong file extension",
-			providedReq:    txtReq,
+			providedReq:    unsupportedReq,
 			expectedResult: nil,
 			expectedErr:    errFileExtension,
 		},
@@ -343,12 +475,36 @@ func Test_extractData(t *testing.T) {
 			expectedResult: [][]string{{"1", "2", "3"}, {"4", "5", "6"}, {"7", "8", "9"}},
 			expectedErr:    nil,
 		},
+		{
+			name:           "success: plain text file provided",
+			providedReq:    txtReq,
+			expectedResult: [][]string{{"1", "2", "3"}, {"4", "5", "6"}, {"7", "8", "9"}},
+			expectedErr:    nil,
+		},
+		{
+			name:           "success: tsv file provided",
+			providedReq:    tsvReq,
+			expectedResult: [][]string{{"1", "2", "3"}, {"4", "5", "6"}, {"7", "8", "9"}},
+			expectedErr:    nil,
+		},
+		{
+			name:           "success: uppercase extension",
+			providedReq:    upperReq,
+			expectedResult: [][]string{{"1", "2", "3"}, {"4", "5", "6"}, {"7", "8", "9"}},
+			expectedErr:    nil,
+		},
+		{
+			name:           "success: xlsx file provided",
+			providedReq:    xlsxReq,
+			expectedResult: [][]string{{"1", "2", "3"}, {"4", "5", "6"}, {"7", "8", "9"}},
+			expectedErr:    nil,
+		},
 	}
 
 	for _, tc := range tt {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			res, err := extractData(tc.providedReq, fileKey)
+			res, err := router.extractData(tc.providedReq, fileKey)
 
 			assert.Equal(t, tc.expectedResult, res)
 			assert.Equal(t, tc.expectedErr, err)
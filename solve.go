@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"math"
+	"net/http"
+)
+
+const (
+	rhsKey = "rhs"
+
+	solveMethodLU           = "lu"
+	solveMethodLeastSquares = "least_squares"
+	solveMethodMinNorm      = "min_norm"
+)
+
+var (
+	errSingularMatrix    = errors.New("system is singular, coefficient matrix has no full rank")
+	errDimensionMismatch = errors.New("right-hand side should have the same number of rows as coefficient matrix")
+)
+
+type solution struct {
+	Method   string      `json:"method"`
+	Solution [][]float64 `json:"solution"`
+	Residual float64     `json:"residual"`
+}
+
+// INFO: minimum norm solution of underdetermined system with full row rank. With A^T = Q*R the system is
+// R1^T*Y = B where R1 is the upper m x m part of R, and X = Q1*Y where Q1 is the first m columns of Q.
+func minNormSolve(ctx context.Context, a, b [][]float64, threshold float64) ([][]float64, error) {
+	m, n, k := len(a), len(a[0]), len(b[0])
+	at, err := transposeDense(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+	q, r, err := qrDecompose(ctx, at)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < m; i++ {
+		if math.Abs(r[i][i]) < threshold {
+			return nil, errSingularMatrix
+		}
+	}
+
+	// forward substitution R1^T*y = b
+	y := newDense(m, k)
+	for c := 0; c < k; c++ {
+		for i := 0; i < m; i++ {
+			s := b[i][c]
+			for j := 0; j < i; j++ {
+				s -= r[j][i] * y[j][c]
+			}
+			y[i][c] = s / r[i][i]
+		}
+	}
+
+	x := newDense(n, k)
+	for i := 0; i < n; i++ {
+		for c := 0; c < k; c++ {
+			var s float64
+			for j := 0; j < m; j++ {
+				s += q[i][j] * y[j][c]
+			}
+			x[i][c] = s
+		}
+	}
+	return x, nil
+}
+
+// INFO: solves A*X = B. Square system is solved with LU with partial pivoting, overdetermined one in least
+// squares sense with QR and underdetermined one gets the solution with minimal norm. Matrix is treated as
+// singular when pivot is smaller than tol relative to the largest element of A.
+func solveSystem(ctx context.Context, a, b [][]float64, tol float64) (solution, error) {
+	m, n := len(a), len(a[0])
+	if len(b) != m {
+		return solution{}, errDimensionMismatch
+	}
+	threshold := tol * math.Max(maxAbs(a), 1)
+
+	var (
+		x      [][]float64
+		method string
+		err    error
+	)
+	if m == n {
+		perm, l, u, _ := luDecompose(a)
+		for i := range u {
+			if math.Abs(u[i][i]) < threshold {
+				return solution{}, errSingularMatrix
+			}
+		}
+		x, method = luSolve(perm, l, u, b), solveMethodLU
+	} else if m < n {
+		x, err = minNormSolve(ctx, a, b, threshold)
+		if err != nil {
+			return solution{}, err
+		}
+		method = solveMethodMinNorm
+	} else {
+		q, r, err := qrDecompose(ctx, a)
+		if err != nil {
+			return solution{}, err
+		}
+		for i := 0; i < n; i++ {
+			if math.Abs(r[i][i]) < threshold {
+				return solution{}, errSingularMatrix
+			}
+		}
+		qt, err := transposeDense(ctx, q)
+		if err != nil {
+			return solution{}, err
+		}
+		qtb, err := mulDense(ctx, qt, b)
+		if err != nil {
+			return solution{}, err
+		}
+		x, method = backSubstitute(r, qtb), solveMethodLeastSquares
+	}
+
+	residual, err := residualNorm(ctx, a, x, b)
+	if err != nil {
+		return solution{}, err
+	}
+	return solution{
+		Method:   method,
+		Solution: snap(x, tol),
+		Residual: residual,
+	}, nil
+}
+
+func (rout *Router) Solve(w http.ResponseWriter, r *http.Request) {
+	coefficients, err := rout.parseMatrix(r, fileKey)
+	if err != nil {
+		rout.log.Error("extracting data from .csv file failed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rhs, err := rout.parseMatrix(r, rhsKey)
+	if err != nil {
+		rout.log.Error("extracting right-hand side from .csv file failed", zap.Error(err))
+		http.Error(w, fmt.Sprintf("%s: %s", rhsKey, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	tol, _, err := parseNumericParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	a, err := matrixToFloat(coefficients)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	b, err := matrixToFloat(rhs)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%s: %s", rhsKey, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	res, err := solveSystem(r.Context(), a, b, tol)
+	rout.log.Info("Solve command called")
+	if err != nil {
+		status := numericErrorStatus(err)
+		switch {
+		case errors.Is(err, errDimensionMismatch):
+			status = http.StatusBadRequest
+		case errors.Is(err, errSingularMatrix):
+			status = http.StatusUnprocessableEntity
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	err = writeJSON(w, http.StatusOK, res)
+	if err != nil {
+		rout.log.Error("writing response failed", zap.Error(err))
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const (
+	systemPath = "./data/system.csv"
+	rhsPath    = "./data/rhs.csv"
+)
+
+func Test_solveSystem(t *testing.T) {
+	square := [][]float64{{2, 1, -1}, {-3, -1, 2}, {-2, 1, 2}}
+	singular := [][]float64{{1, 2}, {2, 4}}
+	tall := [][]float64{{1, 0}, {0, 1}, {1, 1}}
+
+	tt := []struct {
+		name             string
+		providedA        [][]float64
+		providedB        [][]float64
+		expectedMethod   string
+		expectedSolution [][]float64
+		expectedResidual float64
+		expectedErr      error
+	}{
+		{
+			name:        "fail: dimension mismatch",
+			providedA:   square,
+			providedB:   [][]float64{{1}, {2}},
+			expectedErr: errDimensionMismatch,
+		},
+		{
+			name:        "fail: singular matrix",
+			providedA:   singular,
+			providedB:   [][]float64{{1}, {2}},
+			expectedErr: errSingularMatrix,
+		},
+		{
+			name:        "fail: underdetermined system without full row rank",
+			providedA:   [][]float64{{1, 2, 3}, {2, 4, 6}},
+			providedB:   [][]float64{{1}, {2}},
+			expectedErr: errSingularMatrix,
+		},
+		{
+			name:             "success: square system with several right-hand sides",
+			providedA:        square,
+			providedB:        [][]float64{{8, 2}, {-11, -3}, {-3, -2}},
+			expectedMethod:   solveMethodLU,
+			expectedSolution: [][]float64{{2, 1}, {3, 0}, {-1, 0}},
+			expectedResidual: 0,
+		},
+		{
+			name:             "success: least squares",
+			providedA:        tall,
+			providedB:        [][]float64{{1}, {1}, {0}},
+			expectedMethod:   solveMethodLeastSquares,
+			expectedSolution: [][]float64{{1.0 / 3}, {1.0 / 3}},
+			expectedResidual: 1.1547005383792515,
+		},
+		{
+			name:             "success: minimum norm",
+			providedA:        [][]float64{{1, 2, 3}},
+			providedB:        [][]float64{{14}},
+			expectedMethod:   solveMethodMinNorm,
+			expectedSolution: [][]float64{{1}, {2}, {3}},
+			expectedResidual: 0,
+		},
+		{
+			name:             "success: minimum norm with several right-hand sides",
+			providedA:        [][]float64{{1, 0, 1}, {0, 1, 1}},
+			providedB:        [][]float64{{2, 1}, {2, 0}},
+			expectedMethod:   solveMethodMinNorm,
+			expectedSolution: [][]float64{{2.0 / 3, 2.0 / 3}, {2.0 / 3, -1.0 / 3}, {4.0 / 3, 1.0 / 3}},
+			expectedResidual: 0,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := solveSystem(context.Background(), tc.providedA, tc.providedB, defaultTolerance)
+			assert.ErrorIs(t, err, tc.expectedErr)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.expectedMethod, res.Method)
+			assertDenseEqual(t, tc.expectedSolution, res.Solution)
+			assert.InDelta(t, tc.expectedResidual, res.Residual, testDelta)
+		})
+	}
+}
+
+func TestRouter_Solve(t *testing.T) {
+	router, err := setupRouter()
+	assert.NoError(t, err)
+
+	singularReq, err := createFilesReq(testURL, formFile{key: fileKey, path: validPath}, formFile{key: rhsKey, path: rhsPath})
+	assert.NoError(t, err)
+
+	mismatchReq, err := createFilesReq(testURL, formFile{key: fileKey, path: systemPath}, formFile{key: rhsKey, path: notSquarePath})
+	assert.NoError(t, err)
+
+	noRHSReq, err := createFilesReq(testURL, formFile{key: fileKey, path: systemPath})
+	assert.NoError(t, err)
+
+	notNumberReq, err := createFilesReq(testURL, formFile{key: fileKey, path: systemPath}, formFile{key: rhsKey, path: notNumberPath})
+	assert.NoError(t, err)
+
+	tt := []struct {
+		name         string
+		providedReq  *http.Request
+		expectedBody string
+		expectedCode int
+	}{
+		{
+			name:         "fail: no right-hand side - BadRequest",
+			providedReq:  noRHSReq,
+			expectedBody: "rhs: http: no such file\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "fail: dimension mismatch - BadRequest",
+			providedReq:  mismatchReq,
+			expectedBody: errDimensionMismatch.Error() + "\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "fail: not a number - BadRequest",
+			providedReq:  notNumberReq,
+			expectedBody: rhsKey + ": " + errNotNumber.Error() + "\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "fail: singular matrix - UnprocessableEntity",
+			providedReq:  singularReq,
+			expectedBody: errSingularMatrix.Error() + "\n",
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.Solve(w, tc.providedReq)
+
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestRouter_Solve_success(t *testing.T) {
+	router, err := setupRouter()
+	assert.NoError(t, err)
+
+	req, err := createFilesReq(testURL, formFile{key: fileKey, path: systemPath}, formFile{key: rhsKey, path: rhsPath})
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	router.Solve(w, req)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+	var res solution
+	err = json.NewDecoder(w.Body).Decode(&res)
+	assert.NoError(t, err)
+	assert.Equal(t, solveMethodLU, res.Method)
+	assertDenseEqual(t, [][]float64{{2}, {3}, {-1}}, res.Solution)
+	assert.InDelta(t, 0, res.Residual, testDelta)
+
+	// INFO: 2x3 system has infinitely many solutions, the one with minimal norm is returned.
+	req, err = createFilesReq(testURL, formFile{key: fileKey, path: notSquarePath}, formFile{key: rhsKey, path: notSquarePath})
+	assert.NoError(t, err)
+	w = httptest.NewRecorder()
+	router.Solve(w, req)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+	res = solution{}
+	err = json.NewDecoder(w.Body).Decode(&res)
+	assert.NoError(t, err)
+	assert.Equal(t, solveMethodMinNorm, res.Method)
+	assert.Len(t, res.Solution, 3)
+	assert.InDelta(t, 0, res.Residual, testDelta)
+}
@@ -0,0 +1,602 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"io"
+	"math"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// sparse end-points paths
+	sparseTranspose = "/sparse/transpose"
+	sparseSum       = "/sparse/sum"
+	sparseMultiply  = "/sparse/multiply"
+	sparseMatmul    = "/sparse/matmul"
+	sparseStats     = "/sparse/stats"
+
+	otherKey  = "other"
+	outputKey = "output"
+
+	outputSparse = "sparse"
+	outputDense  = "dense"
+
+	mtxExt = ".mtx"
+	cooExt = ".coo"
+
+	mtxBanner = "%%MatrixMarket"
+
+	// INFO: dense output of huge sparse matrix would take gigabytes, so it's limited by number of cells.
+	maxDenseCells = 10_000_000
+	// INFO: row pointers and accumulators are allocated by dimensions, so they are limited independently of
+	// the number of stored entries. Number of cells of matrix within the limit fits into int64.
+	maxSparseDim = 1_000_000
+	// INFO: product of sparse matrices within maxSparseDim may have up to maxSparseDim^2 entries, e.g. column
+	// by row, so number of entries of the result is limited too.
+	maxSparseNNZ = 10_000_000
+	// INFO: number of entries declared in Matrix Market size line is only a hint for preallocation.
+	maxEntriesHint = 1 << 16
+)
+
+var (
+	errSparseExtension = errors.New("invalid file extension, should be \"*.mtx\", \"*.coo\" or \"*.csv\" with row,col,value triplets")
+	errInvalidTriplet  = errors.New("invalid triplet, should be \"row,col,value\" with non-negative indexes")
+	errInvalidMtx      = errors.New("invalid Matrix Market file")
+	errUnsupportedMtx  = errors.New("unsupported Matrix Market format, only \"matrix coordinate\" with real, integer or pattern field is supported")
+	errInvalidOutput   = errors.New("invalid output, should be one of \"sparse\", \"dense\"")
+	errTooLargeDense   = errors.New("matrix is too large for dense output, use output=sparse")
+	errMatmulShape     = errors.New("number of columns of the first matrix should be equal to number of rows of the second one")
+	errTooLargeSparse  = fmt.Errorf("matrix dimensions should not exceed %d", maxSparseDim)
+	errTooLargeProduct = fmt.Errorf("product should not have more than %d non-zero entries", maxSparseNNZ)
+)
+
+type cooEntry struct {
+	row   int
+	col   int
+	value float64
+}
+
+// INFO: coordinate list representation, used as an intermediate format while reading input. Entries may be
+// unordered and contain duplicates.
+type coo struct {
+	rows    int
+	cols    int
+	entries []cooEntry
+}
+
+// INFO: compressed sparse row representation. Non-zero values of row i are values[rowPtr[i]:rowPtr[i+1]]
+// with columns from colIdx in increasing order.
+type csr struct {
+	rows   int
+	cols   int
+	rowPtr []int
+	colIdx []int
+	values []float64
+}
+
+// INFO: converts coordinate list to CSR, duplicates are summed and explicit zeros are dropped.
+func (m coo) toCSR() csr {
+	entries := append([]cooEntry(nil), m.entries...)
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].row != entries[j].row {
+			return entries[i].row < entries[j].row
+		}
+		return entries[i].col < entries[j].col
+	})
+
+	res := csr{rows: m.rows, cols: m.cols, rowPtr: make([]int, m.rows+1)}
+	for i := 0; i < len(entries); {
+		e := entries[i]
+		for i++; i < len(entries) && entries[i].row == e.row && entries[i].col == e.col; i++ {
+			e.value += entries[i].value
+		}
+		if e.value == 0 {
+			continue
+		}
+		res.colIdx = append(res.colIdx, e.col)
+		res.values = append(res.values, e.value)
+		res.rowPtr[e.row+1]++
+	}
+	for i := 0; i < m.rows; i++ {
+		res.rowPtr[i+1] += res.rowPtr[i]
+	}
+	return res
+}
+
+func (m csr) nnz() int {
+	return len(m.values)
+}
+
+// INFO: number of cells including implicit zeros, dimensions are limited by maxSparseDim so it doesn't overflow.
+func (m csr) cells() int64 {
+	return int64(m.rows) * int64(m.cols)
+}
+
+func (m csr) transpose() csr {
+	res := csr{
+		rows:   m.cols,
+		cols:   m.rows,
+		rowPtr: make([]int, m.cols+1),
+		colIdx: make([]int, m.nnz()),
+		values: make([]float64, m.nnz()),
+	}
+	for _, c := range m.colIdx {
+		res.rowPtr[c+1]++
+	}
+	for i := 0; i < m.cols; i++ {
+		res.rowPtr[i+1] += res.rowPtr[i]
+	}
+
+	next := append([]int(nil), res.rowPtr[:m.cols]...)
+	for i := 0; i < m.rows; i++ {
+		for k := m.rowPtr[i]; k < m.rowPtr[i+1]; k++ {
+			c := m.colIdx[k]
+			res.colIdx[next[c]] = i
+			res.values[next[c]] = m.values[k]
+			next[c]++
+		}
+	}
+	return res
+}
+
+func (m csr) sum() float64 {
+	var res float64
+	for _, v := range m.values {
+		res += v
+	}
+	return res
+}
+
+// INFO: product of all elements, which is zero as soon as at least one element isn't stored.
+func (m csr) product() float64 {
+	if int64(m.nnz()) < m.cells() {
+		return 0
+	}
+	res := 1.0
+	for _, v := range m.values {
+		res *= v
+	}
+	return res
+}
+
+// INFO: sparse matrix product with row-by-row accumulation (Gustavson's algorithm). Size of the result is
+// counted before values are computed, so too large product is rejected without allocating it.
+func (m csr) matmul(other csr) (csr, error) {
+	if m.cols != other.rows {
+		return csr{}, errMatmulShape
+	}
+	if !m.productFits(other) {
+		return csr{}, errTooLargeProduct
+	}
+
+	res := csr{rows: m.rows, cols: other.cols, rowPtr: make([]int, m.rows+1)}
+	acc := make([]float64, other.cols)
+	used := make([]bool, other.cols)
+	var cols []int
+	for i := 0; i < m.rows; i++ {
+		cols = cols[:0]
+		for k := m.rowPtr[i]; k < m.rowPtr[i+1]; k++ {
+			a := m.values[k]
+			j := m.colIdx[k]
+			for l := other.rowPtr[j]; l < other.rowPtr[j+1]; l++ {
+				c := other.colIdx[l]
+				if !used[c] {
+					used[c] = true
+					cols = append(cols, c)
+				}
+				acc[c] += a * other.values[l]
+			}
+		}
+
+		sort.Ints(cols)
+		for _, c := range cols {
+			if acc[c] != 0 {
+				res.colIdx = append(res.colIdx, c)
+				res.values = append(res.values, acc[c])
+			}
+			acc[c], used[c] = 0, false
+		}
+		res.rowPtr[i+1] = len(res.values)
+	}
+	return res, nil
+}
+
+// INFO: symbolic pass of matmul, counts entries of the product row by row and stops once they exceed
+// maxSparseNNZ. Entries which cancel out are counted too, so the count is an upper bound.
+func (m csr) productFits(other csr) bool {
+	used := make([]bool, other.cols)
+	var cols []int
+	total := 0
+	for i := 0; i < m.rows; i++ {
+		cols = cols[:0]
+		for k := m.rowPtr[i]; k < m.rowPtr[i+1]; k++ {
+			j := m.colIdx[k]
+			for l := other.rowPtr[j]; l < other.rowPtr[j+1]; l++ {
+				if c := other.colIdx[l]; !used[c] {
+					used[c] = true
+					cols = append(cols, c)
+				}
+			}
+		}
+		for _, c := range cols {
+			used[c] = false
+		}
+		total += len(cols)
+		if total > maxSparseNNZ {
+			return false
+		}
+	}
+	return true
+}
+
+type sparseSummary struct {
+	Rows     int     `json:"rows"`
+	Cols     int     `json:"cols"`
+	Count    int     `json:"count"`
+	NonZero  int     `json:"nnz"`
+	Density  float64 `json:"density"`
+	Min      float64 `json:"min"`
+	Max      float64 `json:"max"`
+	Mean     float64 `json:"mean"`
+	Variance float64 `json:"variance"`
+	StdDev   float64 `json:"stddev"`
+}
+
+// INFO: statistics of the whole matrix including implicit zeros. Zeros are merged into the accumulator as a
+// single group instead of being iterated one by one.
+func (m csr) stats() sparseSummary {
+	var acc welford
+	for _, v := range m.values {
+		acc.add(v)
+	}
+	zeros := m.cells() - int64(m.nnz())
+	if zeros > 0 {
+		acc = acc.merge(welford{count: int(zeros)})
+	}
+
+	return sparseSummary{
+		Rows:     m.rows,
+		Cols:     m.cols,
+		Count:    acc.count,
+		NonZero:  m.nnz(),
+		Density:  float64(m.nnz()) / float64(m.cells()),
+		Min:      acc.min,
+		Max:      acc.max,
+		Mean:     acc.mean,
+		Variance: acc.variance(),
+		StdDev:   math.Sqrt(acc.variance()),
+	}
+}
+
+func (m csr) toDense() ([][]string, error) {
+	if m.cells() > maxDenseCells {
+		return nil, errTooLargeDense
+	}
+
+	res := make([][]string, m.rows)
+	for i := range res {
+		res[i] = make([]string, m.cols)
+		for j := range res[i] {
+			res[i][j] = "0"
+		}
+		for k := m.rowPtr[i]; k < m.rowPtr[i+1]; k++ {
+			res[i][m.colIdx[k]] = formatFloat(m.values[k])
+		}
+	}
+	return res, nil
+}
+
+// INFO: writes non-zero elements as 0-based "row,col,value" triplets, same format as accepted on input.
+func (m csr) toTriplets() [][]string {
+	res := make([][]string, 0, m.nnz())
+	for i := 0; i < m.rows; i++ {
+		for k := m.rowPtr[i]; k < m.rowPtr[i+1]; k++ {
+			res = append(res, []string{strconv.Itoa(i), strconv.Itoa(m.colIdx[k]), formatFloat(m.values[k])})
+		}
+	}
+	return res
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// INFO: reads 0-based "row,col,value" triplets. Shape is taken from rows/cols hints if they are positive,
+// otherwise it's inferred from the largest indexes.
+func parseTriplets(r io.Reader, rows, cols int) (coo, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return coo{}, err
+	}
+	if len(records) == 0 {
+		return coo{}, errEmptyFile
+	}
+
+	res := coo{rows: rows, cols: cols, entries: make([]cooEntry, 0, len(records))}
+	for _, record := range records {
+		if len(record) != 3 {
+			return coo{}, errInvalidTriplet
+		}
+		row, rowErr := strconv.Atoi(strings.TrimSpace(record[0]))
+		col, colErr := strconv.Atoi(strings.TrimSpace(record[1]))
+		value, valueErr := strconv.ParseFloat(strings.TrimSpace(record[2]), 64)
+		if rowErr != nil || colErr != nil || valueErr != nil || row < 0 || col < 0 {
+			return coo{}, errInvalidTriplet
+		}
+		res.entries = append(res.entries, cooEntry{row: row, col: col, value: value})
+	}
+	return res.fitShape(rows, cols)
+}
+
+func (m coo) fitShape(rows, cols int) (coo, error) {
+	maxRow, maxCol := -1, -1
+	for _, e := range m.entries {
+		if e.row > maxRow {
+			maxRow = e.row
+		}
+		if e.col > maxCol {
+			maxCol = e.col
+		}
+	}
+	if rows <= 0 {
+		rows = maxRow + 1
+	}
+	if cols <= 0 {
+		cols = maxCol + 1
+	}
+	if rows > maxSparseDim || cols > maxSparseDim {
+		return coo{}, fmt.Errorf("%w, got %dx%d", errTooLargeSparse, rows, cols)
+	}
+	if maxRow >= rows || maxCol >= cols {
+		return coo{}, fmt.Errorf("%w: entry (%d, %d) is outside of %dx%d matrix", errIndexOutOfRange, maxRow, maxCol, rows, cols)
+	}
+	m.rows, m.cols = rows, cols
+	return m, nil
+}
+
+// INFO: reads Matrix Market coordinate format. Indexes are 1-based, symmetric and skew-symmetric matrices
+// are stored as lower triangle only, so mirrored entries are restored here.
+func parseMatrixMarket(r io.Reader) (coo, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() {
+		return coo{}, errEmptyFile
+	}
+	banner := strings.Fields(strings.ToLower(scanner.Text()))
+	if len(banner) != 5 || banner[0] != strings.ToLower(mtxBanner) {
+		return coo{}, errInvalidMtx
+	}
+	field, symmetry := banner[3], banner[4]
+	if banner[1] != "matrix" || banner[2] != "coordinate" ||
+		(field != "real" && field != "integer" && field != "pattern") ||
+		(symmetry != "general" && symmetry != "symmetric" && symmetry != "skew-symmetric") {
+		return coo{}, errUnsupportedMtx
+	}
+
+	var res coo
+	sizeRead := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "%") {
+			continue
+		}
+		fields := strings.Fields(line)
+
+		if !sizeRead {
+			var nnz int
+			_, err := fmt.Sscan(line, &res.rows, &res.cols, &nnz)
+			if err != nil || res.rows <= 0 || res.cols <= 0 || nnz < 0 {
+				return coo{}, fmt.Errorf("%w: invalid size line %q", errInvalidMtx, line)
+			}
+			if res.rows > maxSparseDim || res.cols > maxSparseDim {
+				return coo{}, fmt.Errorf("%w, got %dx%d", errTooLargeSparse, res.rows, res.cols)
+			}
+			res.entries = make([]cooEntry, 0, minInt(nnz, maxEntriesHint))
+			sizeRead = true
+			continue
+		}
+
+		if (field == "pattern" && len(fields) != 2) || (field != "pattern" && len(fields) != 3) {
+			return coo{}, fmt.Errorf("%w: invalid entry %q", errInvalidMtx, line)
+		}
+		row, rowErr := strconv.Atoi(fields[0])
+		col, colErr := strconv.Atoi(fields[1])
+		value, valueErr := 1.0, error(nil)
+		if field != "pattern" {
+			value, valueErr = strconv.ParseFloat(fields[2], 64)
+		}
+		if rowErr != nil || colErr != nil || valueErr != nil || row < 1 || col < 1 || row > res.rows || col > res.cols {
+			return coo{}, fmt.Errorf("%w: invalid entry %q", errInvalidMtx, line)
+		}
+
+		res.entries = append(res.entries, cooEntry{row: row - 1, col: col - 1, value: value})
+		if row != col && symmetry != "general" {
+			mirrored := value
+			if symmetry == "skew-symmetric" {
+				mirrored = -value
+			}
+			res.entries = append(res.entries, cooEntry{row: col - 1, col: row - 1, value: mirrored})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return coo{}, err
+	}
+	if !sizeRead {
+		return coo{}, fmt.Errorf("%w: size line is missing", errInvalidMtx)
+	}
+	return res, nil
+}
+
+// INFO: reads sparse matrix from uploaded file, format is chosen by extension. Optional rows and cols form
+// values set shape of triplets input.
+func (rout *Router) extractSparse(r *http.Request, key string) (csr, error) {
+	if id := r.FormValue(matrixIDParam(key)); id != "" {
+		m, err := rout.matrices.Get(id)
+		if err != nil {
+			return csr{}, err
+		}
+		return denseToCSR(m.Data)
+	}
+
+	file, header, err := r.FormFile(key)
+	if err != nil {
+		return csr{}, err
+	}
+	defer func() {
+		err = file.Close()
+	}()
+
+	content, filename, err := decompressFile(file, header.Filename, rout.maxExpandedSize)
+	if err != nil {
+		return csr{}, err
+	}
+
+	var m coo
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case mtxExt:
+		m, err = parseMatrixMarket(content)
+	case cooExt, csvExt:
+		rows, _ := strconv.Atoi(r.FormValue(rowsKey))
+		cols, _ := strconv.Atoi(r.FormValue(colsKey))
+		m, err = parseTriplets(content, rows, cols)
+	default:
+		return csr{}, errSparseExtension
+	}
+	if err != nil {
+		return csr{}, err
+	}
+
+	return m.toCSR(), nil
+}
+
+// INFO: converts stored dense matrix to CSR, zeros are dropped.
+func denseToCSR(matrix [][]string) (csr, error) {
+	m := coo{rows: len(matrix), cols: len(matrix[0])}
+	for i, row := range matrix {
+		for j, v := range row {
+			value, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return csr{}, errNotNumber
+			}
+			if value != 0 {
+				m.entries = append(m.entries, cooEntry{row: i, col: j, value: value})
+			}
+		}
+	}
+	return m.toCSR(), nil
+}
+
+func (rout *Router) writeSparse(w http.ResponseWriter, r *http.Request, m csr) {
+	output := r.FormValue(outputKey)
+	var (
+		matrix [][]string
+		err    error
+	)
+	switch output {
+	case "", outputSparse:
+		matrix = m.toTriplets()
+	case outputDense:
+		matrix, err = m.toDense()
+	default:
+		err = errInvalidOutput
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rout.writeMatrix(w, matrix)
+}
+
+func (rout *Router) writeScalar(w http.ResponseWriter, v float64) {
+	w.WriteHeader(http.StatusOK)
+
+	_, err := fmt.Fprintln(w, formatFloat(v))
+	if err != nil {
+		rout.log.Error("writing response failed", zap.Error(err))
+	}
+}
+
+func (rout *Router) SparseTranspose(w http.ResponseWriter, r *http.Request) {
+	m, err := rout.extractSparse(r, fileKey)
+	if err != nil {
+		rout.log.Error("extracting sparse data failed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rout.log.Info("SparseTranspose command called", zap.Int("nnz", m.nnz()))
+	rout.writeSparse(w, r, m.transpose())
+}
+
+func (rout *Router) SparseSum(w http.ResponseWriter, r *http.Request) {
+	m, err := rout.extractSparse(r, fileKey)
+	if err != nil {
+		rout.log.Error("extracting sparse data failed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rout.log.Info("SparseSum command called", zap.Int("nnz", m.nnz()))
+	rout.writeScalar(w, m.sum())
+}
+
+func (rout *Router) SparseMultiply(w http.ResponseWriter, r *http.Request) {
+	m, err := rout.extractSparse(r, fileKey)
+	if err != nil {
+		rout.log.Error("extracting sparse data failed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rout.log.Info("SparseMultiply command called", zap.Int("nnz", m.nnz()))
+	rout.writeScalar(w, m.product())
+}
+
+func (rout *Router) SparseMatmul(w http.ResponseWriter, r *http.Request) {
+	m, err := rout.extractSparse(r, fileKey)
+	if err != nil {
+		rout.log.Error("extracting sparse data failed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	other, err := rout.extractSparse(r, otherKey)
+	if err != nil {
+		rout.log.Error("extracting sparse data failed", zap.Error(err))
+		http.Error(w, fmt.Sprintf("%s: %s", otherKey, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	res, err := m.matmul(other)
+	rout.log.Info("SparseMatmul command called", zap.Int("nnz", m.nnz()), zap.Int("otherNnz", other.nnz()))
+	if errors.Is(err, errTooLargeProduct) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rout.writeSparse(w, r, res)
+}
+
+func (rout *Router) SparseStats(w http.ResponseWriter, r *http.Request) {
+	m, err := rout.extractSparse(r, fileKey)
+	if err != nil {
+		rout.log.Error("extracting sparse data failed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rout.log.Info("SparseStats command called", zap.Int("nnz", m.nnz()))
+	err = writeJSON(w, http.StatusOK, m.stats())
+	if err != nil {
+		rout.log.Error("writing response failed", zap.Error(err))
+	}
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	mtxPath = "./data/sparse.mtx"
+	cooPath = "./data/sparse.coo"
+)
+
+func Test_coo_toCSR(t *testing.T) {
+	m := coo{rows: 3, cols: 3, entries: []cooEntry{
+		{row: 2, col: 0, value: 4},
+		{row: 0, col: 2, value: 1},
+		{row: 0, col: 2, value: 0.5},
+		{row: 1, col: 1, value: 3},
+		{row: 1, col: 1, value: -3},
+		{row: 0, col: 0, value: 2},
+	}}
+
+	res := m.toCSR()
+	assert.Equal(t, csr{
+		rows:   3,
+		cols:   3,
+		rowPtr: []int{0, 2, 2, 3},
+		colIdx: []int{0, 2, 0},
+		values: []float64{2, 1.5, 4},
+	}, res)
+}
+
+func Test_csr_operations(t *testing.T) {
+	a := coo{rows: 2, cols: 3, entries: []cooEntry{
+		{row: 0, col: 0, value: 1},
+		{row: 0, col: 2, value: 2},
+		{row: 1, col: 1, value: 3},
+	}}.toCSR()
+	b := coo{rows: 3, cols: 2, entries: []cooEntry{
+		{row: 0, col: 1, value: 4},
+		{row: 1, col: 0, value: 5},
+		{row: 2, col: 1, value: 6},
+	}}.toCSR()
+
+	t.Run("success: transpose", func(t *testing.T) {
+		res, err := a.transpose().toDense()
+		assert.NoError(t, err)
+		assert.Equal(t, [][]string{{"1", "0"}, {"0", "3"}, {"2", "0"}}, res)
+	})
+
+	t.Run("success: sum and product", func(t *testing.T) {
+		assert.Equal(t, 6.0, a.sum())
+		assert.Equal(t, 0.0, a.product())
+
+		full := coo{rows: 1, cols: 2, entries: []cooEntry{{col: 0, value: 3}, {col: 1, value: -2}}}.toCSR()
+		assert.Equal(t, -6.0, full.product())
+	})
+
+	t.Run("success: matmul", func(t *testing.T) {
+		res, err := a.matmul(b)
+		assert.NoError(t, err)
+		dense, err := res.toDense()
+		assert.NoError(t, err)
+		assert.Equal(t, [][]string{{"0", "16"}, {"15", "0"}}, dense)
+	})
+
+	t.Run("fail: matmul shape mismatch", func(t *testing.T) {
+		_, err := a.matmul(a)
+		assert.ErrorIs(t, err, errMatmulShape)
+	})
+
+	t.Run("success: stats with implicit zeros", func(t *testing.T) {
+		res := a.stats()
+		assert.Equal(t, 6, res.Count)
+		assert.Equal(t, 3, res.NonZero)
+		assert.InDelta(t, 0.5, res.Density, testDelta)
+		assert.Equal(t, 0.0, res.Min)
+		assert.Equal(t, 3.0, res.Max)
+		assert.InDelta(t, 1.0, res.Mean, testDelta)
+		assert.InDelta(t, 8.0/6, res.Variance, testDelta)
+	})
+
+	t.Run("success: triplets output", func(t *testing.T) {
+		assert.Equal(t, [][]string{{"0", "0", "1"}, {"0", "2", "2"}, {"1", "1", "3"}}, a.toTriplets())
+	})
+}
+
+func Test_parseTriplets(t *testing.T) {
+	tt := []struct {
+		name           string
+		providedData   string
+		providedRows   int
+		providedCols   int
+		expectedResult coo
+		expectedErr    error
+	}{
+		{
+			name:         "fail: wrong number of fields",
+			providedData: "0,0\n",
+			expectedErr:  errInvalidTriplet,
+		},
+		{
+			name:         "fail: negative index",
+			providedData: "-1,0,1\n",
+			expectedErr:  errInvalidTriplet,
+		},
+		{
+			name:         "fail: entry outside of provided shape",
+			providedData: "0,3,1\n",
+			providedRows: 2,
+			providedCols: 2,
+			expectedErr:  errIndexOutOfRange,
+		},
+		{
+			name:         "fail: inferred shape is too large",
+			providedData: "0,0,1\n5000000000,1,2\n",
+			expectedErr:  errTooLargeSparse,
+		},
+		{
+			name:         "fail: provided shape is too large",
+			providedData: "0,0,1\n",
+			providedRows: 1,
+			providedCols: 9223372036854775807,
+			expectedErr:  errTooLargeSparse,
+		},
+		{
+			name:           "success: inferred shape",
+			providedData:   "0,0,1\n2,1,2.5\n",
+			expectedResult: coo{rows: 3, cols: 2, entries: []cooEntry{{0, 0, 1}, {2, 1, 2.5}}},
+		},
+		{
+			name:           "success: provided shape",
+			providedData:   "0,0,1\n",
+			providedRows:   5,
+			providedCols:   4,
+			expectedResult: coo{rows: 5, cols: 4, entries: []cooEntry{{0, 0, 1}}},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := parseTriplets(strings.NewReader(tc.providedData), tc.providedRows, tc.providedCols)
+			assert.Equal(t, tc.expectedResult, res)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func Test_parseMatrixMarket(t *testing.T) {
+	tt := []struct {
+		name           string
+		providedData   string
+		expectedResult coo
+		expectedErr    error
+	}{
+		{
+			name:         "fail: no banner",
+			providedData: "2 2 1\n1 1 1\n",
+			expectedErr:  errInvalidMtx,
+		},
+		{
+			name:         "fail: array format",
+			providedData: "%%MatrixMarket matrix array real general\n2 2\n1\n2\n3\n4\n",
+			expectedErr:  errUnsupportedMtx,
+		},
+		{
+			name:         "fail: entry out of range",
+			providedData: "%%MatrixMarket matrix coordinate real general\n2 2 1\n3 1 1\n",
+			expectedErr:  errInvalidMtx,
+		},
+		{
+			name:         "fail: size is too large",
+			providedData: "%%MatrixMarket matrix coordinate real general\n4294967296 4294967296 1\n1 1 1\n",
+			expectedErr:  errTooLargeSparse,
+		},
+		{
+			name:         "success: general real",
+			providedData: "%%MatrixMarket matrix coordinate real general\n% comment\n2 3 2\n1 1 1.5\n2 3 -2\n",
+			expectedResult: coo{rows: 2, cols: 3, entries: []cooEntry{
+				{row: 0, col: 0, value: 1.5},
+				{row: 1, col: 2, value: -2},
+			}},
+		},
+		{
+			name:         "success: symmetric pattern",
+			providedData: "%%MatrixMarket matrix coordinate pattern symmetric\n2 2 2\n1 1\n2 1\n",
+			expectedResult: coo{rows: 2, cols: 2, entries: []cooEntry{
+				{row: 0, col: 0, value: 1},
+				{row: 1, col: 0, value: 1},
+				{row: 0, col: 1, value: 1},
+			}},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := parseMatrixMarket(strings.NewReader(tc.providedData))
+			assert.Equal(t, tc.expectedResult, res)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestRouter_SparseTranspose(t *testing.T) {
+	router, err := setupRouter()
+	assert.NoError(t, err)
+
+	sparseReq, err := createFilesReq(testURL, formFile{key: fileKey, path: mtxPath})
+	assert.NoError(t, err)
+
+	denseReq, err := createFilesReq(testURL+"?output=dense", formFile{key: fileKey, path: mtxPath})
+	assert.NoError(t, err)
+
+	unsupportedReq, err := createFilesReq(testURL, formFile{key: fileKey, path: unsupportedPath})
+	assert.NoError(t, err)
+
+	tooLargeReq, err := createFilesReq(testURL+"?rows=4000000000&cols=4000000000", formFile{key: fileKey, path: cooPath})
+	assert.NoError(t, err)
+
+	tt := []struct {
+		name         string
+		providedReq  *http.Request
+		expectedBody string
+		expectedCode int
+	}{
+		{
+			name:         "fail: too large dimensions - BadRequest",
+			providedReq:  tooLargeReq,
+			expectedBody: errTooLargeSparse.Error() + ", got 4000000000x4000000000\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "fail: wrong file extension - BadRequest",
+			providedReq:  unsupportedReq,
+			expectedBody: errSparseExtension.Error() + "\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "success: sparse output",
+			providedReq:  sparseReq,
+			expectedBody: "0,0,2\n0,2,4\n1,1,-1\n2,0,1.5\n",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "success: dense output",
+			providedReq:  denseReq,
+			expectedBody: "2,0,4\n0,-1,0\n1.5,0,0\n",
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.SparseTranspose(w, tc.providedReq)
+
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestRouter_SparseMatmul(t *testing.T) {
+	router, err := setupRouter()
+	assert.NoError(t, err)
+
+	successReq, err := createFilesReq(testURL+"?output=dense",
+		formFile{key: fileKey, path: cooPath}, formFile{key: otherKey, path: mtxPath})
+	assert.NoError(t, err)
+
+	noOtherReq, err := createFilesReq(testURL, formFile{key: fileKey, path: cooPath})
+	assert.NoError(t, err)
+
+	// INFO: column by row product has every cell filled.
+	var column, row strings.Builder
+	for i := 0; i < 4000; i++ {
+		fmt.Fprintf(&column, "%d,0,1\n", i)
+		fmt.Fprintf(&row, "0,%d,1\n", i)
+	}
+	columnPath, rowPath := filepath.Join(t.TempDir(), "column.csv"), filepath.Join(t.TempDir(), "row.csv")
+	assert.NoError(t, os.WriteFile(columnPath, []byte(column.String()), 0o600))
+	assert.NoError(t, os.WriteFile(rowPath, []byte(row.String()), 0o600))
+	tooLargeReq, err := createFilesReq(testURL+"?output=sparse",
+		formFile{key: fileKey, path: columnPath}, formFile{key: otherKey, path: rowPath})
+	assert.NoError(t, err)
+
+	tt := []struct {
+		name         string
+		providedReq  *http.Request
+		expectedBody string
+		expectedCode int
+	}{
+		{
+			name:         "fail: no second matrix - BadRequest",
+			providedReq:  noOtherReq,
+			expectedBody: "other: http: no such file\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "fail: too many entries of product - UnprocessableEntity",
+			providedReq:  tooLargeReq,
+			expectedBody: errTooLargeProduct.Error() + "\n",
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "success: multiplied",
+			providedReq:  successReq,
+			expectedBody: "2,0,1.5\n12,0,0\n0,-2,0\n",
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.SparseMatmul(w, tc.providedReq)
+
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestRouter_SparseAggregates(t *testing.T) {
+	router, err := setupRouter()
+	assert.NoError(t, err)
+
+	sumReq, err := createFilesReq(testURL, formFile{key: fileKey, path: mtxPath})
+	assert.NoError(t, err)
+	w := httptest.NewRecorder()
+	router.SparseSum(w, sumReq)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.Equal(t, "6.5\n", w.Body.String())
+
+	multiplyReq, err := createFilesReq(testURL, formFile{key: fileKey, path: mtxPath})
+	assert.NoError(t, err)
+	w = httptest.NewRecorder()
+	router.SparseMultiply(w, multiplyReq)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.Equal(t, "0\n", w.Body.String())
+
+	statsReq, err := createFilesReq(testURL, formFile{key: fileKey, path: cooPath})
+	assert.NoError(t, err)
+	w = httptest.NewRecorder()
+	router.SparseStats(w, statsReq)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.Contains(t, w.Body.String(), `"rows":3,"cols":3,"count":9,"nnz":3`)
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	percentilesKey = "percentiles"
+)
+
+var (
+	errInvalidPercentile = errors.New("invalid percentile, should be a number in range [0, 100]")
+
+	defaultPercentiles = []float64{25, 50, 75, 90, 99}
+)
+
+// INFO: welford accumulates count, min, max, mean and variance in a single pass with Welford's online
+// algorithm, so values don't need to be stored and precision isn't lost on large inputs.
+type welford struct {
+	count int
+	min   float64
+	max   float64
+	mean  float64
+	m2    float64
+}
+
+func (acc *welford) add(x float64) {
+	acc.count++
+	if acc.count == 1 || x < acc.min {
+		acc.min = x
+	}
+	if acc.count == 1 || x > acc.max {
+		acc.max = x
+	}
+	delta := x - acc.mean
+	acc.mean += delta / float64(acc.count)
+	acc.m2 += delta * (x - acc.mean)
+}
+
+// INFO: combines two accumulators with Chan's parallel algorithm, so the result is the same as if all
+// values were added to one of them.
+func (acc welford) merge(other welford) welford {
+	if acc.count == 0 {
+		return other
+	}
+	if other.count == 0 {
+		return acc
+	}
+
+	count := acc.count + other.count
+	delta := other.mean - acc.mean
+	return welford{
+		count: count,
+		min:   math.Min(acc.min, other.min),
+		max:   math.Max(acc.max, other.max),
+		mean:  acc.mean + delta*float64(other.count)/float64(count),
+		m2:    acc.m2 + other.m2 + delta*delta*float64(acc.count)*float64(other.count)/float64(count),
+	}
+}
+
+// INFO: population variance.
+func (acc *welford) variance() float64 {
+	if acc.count == 0 {
+		return 0
+	}
+	return acc.m2 / float64(acc.count)
+}
+
+type summary struct {
+	Count       int                `json:"count"`
+	Min         float64            `json:"min"`
+	Max         float64            `json:"max"`
+	Mean        float64            `json:"mean"`
+	Median      float64            `json:"median"`
+	Variance    float64            `json:"variance"`
+	StdDev      float64            `json:"stddev"`
+	Percentiles map[string]float64 `json:"percentiles"`
+}
+
+type statsResponse struct {
+	Axis  string    `json:"axis"`
+	Stats []summary `json:"stats"`
+}
+
+// INFO: parses comma separated list of percentiles, empty value means defaultPercentiles.
+func parsePercentiles(raw string) ([]float64, error) {
+	if strings.TrimSpace(raw) == "" {
+		return defaultPercentiles, nil
+	}
+
+	var res []float64
+	for _, item := range strings.Split(raw, ",") {
+		p, err := strconv.ParseFloat(strings.TrimSpace(item), 64)
+		if err != nil || p < 0 || p > 100 {
+			return nil, errInvalidPercentile
+		}
+		res = append(res, p)
+	}
+	return res, nil
+}
+
+// INFO: calculates percentile of sorted values with linear interpolation between closest ranks.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+// INFO: moments are calculated in one pass, order statistics (median, percentiles) need sorted copy of values.
+func summarize(values []float64, percentiles []float64) summary {
+	var acc welford
+	for _, v := range values {
+		acc.add(v)
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	res := summary{
+		Count:       acc.count,
+		Min:         acc.min,
+		Max:         acc.max,
+		Mean:        acc.mean,
+		Median:      percentile(sorted, 50),
+		Variance:    acc.variance(),
+		StdDev:      math.Sqrt(acc.variance()),
+		Percentiles: make(map[string]float64, len(percentiles)),
+	}
+	for _, p := range percentiles {
+		res.Percentiles[strconv.FormatFloat(p, 'f', -1, 64)] = percentile(sorted, p)
+	}
+	return res
+}
+
+// INFO: splits matrix values into groups by axis and summarizes each group.
+func statsMatrix(matrix [][]string, axis string, percentiles []float64) ([]summary, error) {
+	floatMatrix, err := matrixToFloat(matrix)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups [][]float64
+	switch axis {
+	case axisAll:
+		var all []float64
+		for _, row := range floatMatrix {
+			all = append(all, row...)
+		}
+		groups = [][]float64{all}
+	case axisRows:
+		groups = floatMatrix
+	case axisCols:
+		groups = make([][]float64, len(floatMatrix[0]))
+		for _, row := range floatMatrix {
+			for j, v := range row {
+				groups[j] = append(groups[j], v)
+			}
+		}
+	default:
+		return nil, errInvalidAxis
+	}
+
+	res := make([]summary, len(groups))
+	for i, group := range groups {
+		res[i] = summarize(group, percentiles)
+	}
+	return res, nil
+}
+
+func (rout *Router) Stats(w http.ResponseWriter, r *http.Request) {
+	matrix, err := rout.extractData(r, fileKey)
+	if err != nil {
+		rout.log.Error("extracting data from .csv file failed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	axis, err := parseAxis(r.FormValue(axisKey))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	percentiles, err := parsePercentiles(r.FormValue(percentilesKey))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res, err := statsMatrix(matrix, axis, percentiles)
+	rout.log.Info("Stats command called", zap.String("axis", axis))
+	if err != nil {
+		http.Error(w, err.Error(), numericErrorStatus(err))
+		return
+	}
+
+	err = writeJSON(w, http.StatusOK, statsResponse{Axis: axis, Stats: res})
+	if err != nil {
+		rout.log.Error("writing response failed", zap.Error(err))
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_welford(t *testing.T) {
+	values := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+
+	var acc welford
+	for _, v := range values {
+		acc.add(v)
+	}
+
+	assert.Equal(t, 8, acc.count)
+	assert.Equal(t, 2.0, acc.min)
+	assert.Equal(t, 9.0, acc.max)
+	assert.InDelta(t, 5.0, acc.mean, 1e-12)
+	assert.InDelta(t, 4.0, acc.variance(), 1e-12)
+}
+
+func Test_welford_merge(t *testing.T) {
+	var left, right, all welford
+	for i, v := range []float64{2, 4, 4, 4, 5, 5, 7, 9} {
+		if i < 3 {
+			left.add(v)
+		} else {
+			right.add(v)
+		}
+		all.add(v)
+	}
+
+	res := left.merge(right)
+	assert.Equal(t, all.count, res.count)
+	assert.Equal(t, all.min, res.min)
+	assert.Equal(t, all.max, res.max)
+	assert.InDelta(t, all.mean, res.mean, 1e-12)
+	assert.InDelta(t, all.variance(), res.variance(), 1e-12)
+	assert.Equal(t, left, left.merge(welford{}))
+}
+
+func Test_parsePercentiles(t *testing.T) {
+	tt := []struct {
+		name           string
+		providedRaw    string
+		expectedResult []float64
+		expectedErr    error
+	}{
+		{
+			name:           "fail: out of range",
+			providedRaw:    "50,101",
+			expectedResult: nil,
+			expectedErr:    errInvalidPercentile,
+		},
+		{
+			name:           "fail: not a number",
+			providedRaw:    "p90",
+			expectedResult: nil,
+			expectedErr:    errInvalidPercentile,
+		},
+		{
+			name:           "success: default percentiles",
+			providedRaw:    "",
+			expectedResult: defaultPercentiles,
+			expectedErr:    nil,
+		},
+		{
+			name:           "success: percentiles parsed",
+			providedRaw:    "10, 99.9",
+			expectedResult: []float64{10, 99.9},
+			expectedErr:    nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := parsePercentiles(tc.providedRaw)
+			assert.Equal(t, tc.expectedResult, res)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func Test_percentile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4}
+
+	tt := []struct {
+		name           string
+		providedP      float64
+		expectedResult float64
+	}{
+		{
+			name:           "success: minimum",
+			providedP:      0,
+			expectedResult: 1,
+		},
+		{
+			name:           "success: interpolated median",
+			providedP:      50,
+			expectedResult: 2.5,
+		},
+		{
+			name:           "success: maximum",
+			providedP:      100,
+			expectedResult: 4,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res := percentile(sorted, tc.providedP)
+			assert.InDelta(t, tc.expectedResult, res, 1e-12)
+		})
+	}
+}
+
+func Test_statsMatrix(t *testing.T) {
+	validMatrix := [][]string{{"1", "2"}, {"3", "4"}}
+	invalidMatrix := [][]string{{"1", "b"}, {"3", "4"}}
+
+	tt := []struct {
+		name           string
+		providedMatrix [][]string
+		providedAxis   string
+		expectedMeans  []float64
+		expectedErr    error
+	}{
+		{
+			name:           "fail: matrix with non-numeric values",
+			providedMatrix: invalidMatrix,
+			providedAxis:   axisAll,
+			expectedMeans:  nil,
+			expectedErr:    errNotNumber,
+		},
+		{
+			name:           "success: whole matrix",
+			providedMatrix: validMatrix,
+			providedAxis:   axisAll,
+			expectedMeans:  []float64{2.5},
+			expectedErr:    nil,
+		},
+		{
+			name:           "success: per row",
+			providedMatrix: validMatrix,
+			providedAxis:   axisRows,
+			expectedMeans:  []float64{1.5, 3.5},
+			expectedErr:    nil,
+		},
+		{
+			name:           "success: per column",
+			providedMatrix: validMatrix,
+			providedAxis:   axisCols,
+			expectedMeans:  []float64{2, 3},
+			expectedErr:    nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := statsMatrix(tc.providedMatrix, tc.providedAxis, defaultPercentiles)
+			var means []float64
+			for _, s := range res {
+				means = append(means, s.Mean)
+			}
+			assert.Equal(t, tc.expectedMeans, means)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestRouter_Stats(t *testing.T) {
+	validBody := `{"axis":"all","stats":[{"count":9,"min":1,"max":9,"mean":5,"median":5,` +
+		`"variance":6.666666666666667,"stddev":2.581988897471611,"percentiles":{"25":3,"50":5}}]}` + "\n"
+	router, err := setupRouter()
+	assert.NoError(t, err)
+
+	successReq, successW, err := createReq(validPath, testURL+"?percentiles=25,50")
+	assert.NoError(t, err)
+	successReq.Header.Set("Content-Type", successW.FormDataContentType())
+
+	invalidReq, invalidW, err := createReq(validPath, testURL+"?percentiles=150")
+	assert.NoError(t, err)
+	invalidReq.Header.Set("Content-Type", invalidW.FormDataContentType())
+
+	notNumberReq, notNumberW, err := createReq(notNumberPath, testURL+"")
+	assert.NoError(t, err)
+	notNumberReq.Header.Set("Content-Type", notNumberW.FormDataContentType())
+
+	unsupportedReq, unsupportedW, err := createReq(unsupportedPath, testURL)
+	assert.NoError(t, err)
+	unsupportedReq.Header.Set("Content-Type", unsupportedW.FormDataContentType())
+
+	tt := []struct {
+		name         string
+		providedReq  *http.Request
+		expectedBody string
+		expectedCode int
+	}{
+		{
+			name:         "fail: extract data - BadRequest",
+			providedReq:  unsupportedReq,
+			expectedBody: errFileExtension.Error() + "\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "fail: invalid percentile - BadRequest",
+			providedReq:  invalidReq,
+			expectedBody: errInvalidPercentile.Error() + "\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "fail: not a number - BadRequest",
+			providedReq:  notNumberReq,
+			expectedBody: errNotNumber.Error() + "\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "success: stats calculated",
+			providedReq:  successReq,
+			expectedBody: validBody,
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.Stats(w, tc.providedReq)
+
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+const (
+	degreesKey   = "degrees"
+	directionKey = "direction"
+	partKey      = "part"
+	fillKey      = "fill"
+
+	directionHorizontal = "horizontal"
+	directionVertical   = "vertical"
+
+	partUpper = "upper"
+	partLower = "lower"
+
+	defaultFill = "0"
+)
+
+var (
+	errInvalidDegrees   = errors.New("invalid degrees, should be a multiple of 90")
+	errInvalidDirection = errors.New("invalid direction, should be one of \"horizontal\", \"vertical\"")
+	errInvalidPart      = errors.New("invalid part, should be one of \"upper\", \"lower\"")
+)
+
+// INFO: converts degrees to a number of clockwise quarter turns in range [0, 3].
+func parseDegrees(raw string) (int, error) {
+	degrees, err := strconv.Atoi(raw)
+	if err != nil || degrees%90 != 0 {
+		return 0, errInvalidDegrees
+	}
+	return ((degrees/90)%4 + 4) % 4, nil
+}
+
+func parseDirection(raw string) (string, error) {
+	if raw != directionHorizontal && raw != directionVertical {
+		return "", errInvalidDirection
+	}
+	return raw, nil
+}
+
+// INFO: rotates matrix clockwise by provided number of quarter turns. Works with any cell values.
+func rotateMatrix(matrix [][]string, turns int) [][]string {
+	for ; turns > 0; turns-- {
+		matrix = rotateClockwise(matrix)
+	}
+	return matrix
+}
+
+func rotateClockwise(matrix [][]string) [][]string {
+	rowsNumber := len(matrix)
+	columnsNumber := len(matrix[0])
+	rotated := make([][]string, columnsNumber)
+	for i := range rotated {
+		rotated[i] = make([]string, rowsNumber)
+	}
+
+	for i := 0; i < rowsNumber; i++ {
+		for j := 0; j < columnsNumber; j++ {
+			rotated[j][rowsNumber-1-i] = matrix[i][j]
+		}
+	}
+
+	return rotated
+}
+
+// INFO: mirrors matrix. Horizontal flip reverses each row, vertical one reverses order of rows.
+func flipMatrix(matrix [][]string, direction string) [][]string {
+	rowsNumber := len(matrix)
+	flipped := make([][]string, rowsNumber)
+	for i, row := range matrix {
+		if direction == directionVertical {
+			flipped[rowsNumber-1-i] = append([]string(nil), row...)
+			continue
+		}
+		flipped[i] = make([]string, len(row))
+		for j := range row {
+			flipped[i][len(row)-1-j] = row[j]
+		}
+	}
+	return flipped
+}
+
+// INFO: transposes matrix over the anti-diagonal (from top-right to bottom-left corner).
+func antiTransposeMatrix(matrix [][]string) [][]string {
+	rowsNumber := len(matrix)
+	columnsNumber := len(matrix[0])
+	res := make([][]string, columnsNumber)
+	for i := range res {
+		res[i] = make([]string, rowsNumber)
+	}
+
+	for i := 0; i < rowsNumber; i++ {
+		for j := 0; j < columnsNumber; j++ {
+			res[columnsNumber-1-j][rowsNumber-1-i] = matrix[i][j]
+		}
+	}
+
+	return res
+}
+
+// INFO: extracts main diagonal as a single row.
+func diagonalMatrix(matrix [][]string) [][]string {
+	var diagonal []string
+	for i := 0; i < len(matrix) && i < len(matrix[i]); i++ {
+		diagonal = append(diagonal, matrix[i][i])
+	}
+	return [][]string{diagonal}
+}
+
+// INFO: keeps upper or lower triangle including main diagonal, other cells are replaced with fill value.
+func triangleMatrix(matrix [][]string, part string, fill string) [][]string {
+	res := make([][]string, len(matrix))
+	for i, row := range matrix {
+		res[i] = make([]string, len(row))
+		for j := range row {
+			keep := j >= i
+			if part == partLower {
+				keep = j <= i
+			}
+			if keep {
+				res[i][j] = row[j]
+			} else {
+				res[i][j] = fill
+			}
+		}
+	}
+	return res
+}
+
+func (rout *Router) Rotate(w http.ResponseWriter, r *http.Request) {
+	matrix, err := rout.parseMatrix(r, fileKey)
+	if err != nil {
+		rout.log.Error("extracting data from .csv file failed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	turns, err := parseDegrees(r.FormValue(degreesKey))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rotated := rotateMatrix(matrix, turns)
+	rout.log.Info("Rotate command called", zap.Int("turns", turns))
+	rout.writeMatrix(w, rotated)
+}
+
+func (rout *Router) Flip(w http.ResponseWriter, r *http.Request) {
+	matrix, err := rout.parseMatrix(r, fileKey)
+	if err != nil {
+		rout.log.Error("extracting data from .csv file failed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	direction, err := parseDirection(r.FormValue(directionKey))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	flipped := flipMatrix(matrix, direction)
+	rout.log.Info("Flip command called", zap.String("direction", direction))
+	rout.writeMatrix(w, flipped)
+}
+
+func (rout *Router) AntiTranspose(w http.ResponseWriter, r *http.Request) {
+	matrix, err := rout.parseMatrix(r, fileKey)
+	if err != nil {
+		rout.log.Error("extracting data from .csv file failed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	transposed := antiTransposeMatrix(matrix)
+	rout.log.Info("AntiTranspose command called")
+	rout.writeMatrix(w, transposed)
+}
+
+func (rout *Router) Diagonal(w http.ResponseWriter, r *http.Request) {
+	matrix, err := rout.parseMatrix(r, fileKey)
+	if err != nil {
+		rout.log.Error("extracting data from .csv file failed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	diagonal := diagonalMatrix(matrix)
+	rout.log.Info("Diagonal command called")
+	rout.writeMatrix(w, diagonal)
+}
+
+func (rout *Router) Triangle(w http.ResponseWriter, r *http.Request) {
+	matrix, err := rout.parseMatrix(r, fileKey)
+	if err != nil {
+		rout.log.Error("extracting data from .csv file failed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	part := r.FormValue(partKey)
+	if part != partUpper && part != partLower {
+		http.Error(w, errInvalidPart.Error(), http.StatusBadRequest)
+		return
+	}
+	fill := defaultFill
+	if values, ok := r.Form[fillKey]; ok {
+		fill = values[0]
+	}
+
+	triangle := triangleMatrix(matrix, part, fill)
+	rout.log.Info("Triangle command called", zap.String("part", part))
+	rout.writeMatrix(w, triangle)
+}
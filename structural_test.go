@@ -0,0 +1,379 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_parseDegrees(t *testing.T) {
+	tt := []struct {
+		name           string
+		providedRaw    string
+		expectedResult int
+		expectedErr    error
+	}{
+		{
+			name:           "fail: not a multiple of 90",
+			providedRaw:    "45",
+			expectedResult: 0,
+			expectedErr:    errInvalidDegrees,
+		},
+		{
+			name:           "fail: not a number",
+			providedRaw:    "",
+			expectedResult: 0,
+			expectedErr:    errInvalidDegrees,
+		},
+		{
+			name:           "success: clockwise",
+			providedRaw:    "270",
+			expectedResult: 3,
+			expectedErr:    nil,
+		},
+		{
+			name:           "success: counterclockwise",
+			providedRaw:    "-90",
+			expectedResult: 3,
+			expectedErr:    nil,
+		},
+		{
+			name:           "success: full turn",
+			providedRaw:    "720",
+			expectedResult: 0,
+			expectedErr:    nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := parseDegrees(tc.providedRaw)
+			assert.Equal(t, tc.expectedResult, res)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func Test_rotateMatrix(t *testing.T) {
+	matrix := [][]string{{"a", "b", "c"}, {"d", "e", "f"}}
+
+	tt := []struct {
+		name           string
+		providedTurns  int
+		expectedResult [][]string
+	}{
+		{
+			name:           "success: no rotation",
+			providedTurns:  0,
+			expectedResult: matrix,
+		},
+		{
+			name:           "success: rotated by 90",
+			providedTurns:  1,
+			expectedResult: [][]string{{"d", "a"}, {"e", "b"}, {"f", "c"}},
+		},
+		{
+			name:           "success: rotated by 180",
+			providedTurns:  2,
+			expectedResult: [][]string{{"f", "e", "d"}, {"c", "b", "a"}},
+		},
+		{
+			name:           "success: rotated by 270",
+			providedTurns:  3,
+			expectedResult: [][]string{{"c", "f"}, {"b", "e"}, {"a", "d"}},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res := rotateMatrix(matrix, tc.providedTurns)
+			assert.Equal(t, tc.expectedResult, res)
+		})
+	}
+}
+
+func Test_flipMatrix(t *testing.T) {
+	matrix := [][]string{{"a", "b"}, {"c", "d"}}
+
+	tt := []struct {
+		name              string
+		providedDirection string
+		expectedResult    [][]string
+	}{
+		{
+			name:              "success: horizontal flip",
+			providedDirection: directionHorizontal,
+			expectedResult:    [][]string{{"b", "a"}, {"d", "c"}},
+		},
+		{
+			name:              "success: vertical flip",
+			providedDirection: directionVertical,
+			expectedResult:    [][]string{{"c", "d"}, {"a", "b"}},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res := flipMatrix(matrix, tc.providedDirection)
+			assert.Equal(t, tc.expectedResult, res)
+		})
+	}
+}
+
+func Test_antiTransposeMatrix(t *testing.T) {
+	matrix := [][]string{{"a", "b", "c"}, {"d", "e", "f"}}
+
+	res := antiTransposeMatrix(matrix)
+	assert.Equal(t, [][]string{{"f", "c"}, {"e", "b"}, {"d", "a"}}, res)
+}
+
+func Test_diagonalMatrix(t *testing.T) {
+	matrix := [][]string{{"a", "b"}, {"c", "d"}}
+
+	res := diagonalMatrix(matrix)
+	assert.Equal(t, [][]string{{"a", "d"}}, res)
+}
+
+func Test_triangleMatrix(t *testing.T) {
+	matrix := [][]string{{"a", "b", "c"}, {"d", "e", "f"}, {"g", "h", "i"}}
+
+	tt := []struct {
+		name           string
+		providedPart   string
+		providedFill   string
+		expectedResult [][]string
+	}{
+		{
+			name:           "success: upper triangle",
+			providedPart:   partUpper,
+			providedFill:   "0",
+			expectedResult: [][]string{{"a", "b", "c"}, {"0", "e", "f"}, {"0", "0", "i"}},
+		},
+		{
+			name:           "success: lower triangle with empty fill",
+			providedPart:   partLower,
+			providedFill:   "",
+			expectedResult: [][]string{{"a", "", ""}, {"d", "e", ""}, {"g", "h", "i"}},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res := triangleMatrix(matrix, tc.providedPart, tc.providedFill)
+			assert.Equal(t, tc.expectedResult, res)
+		})
+	}
+}
+
+func TestRouter_Rotate(t *testing.T) {
+	router, err := setupRouter()
+	assert.NoError(t, err)
+
+	successReq, successW, err := createReq(validPath, testURL+"?degrees=90")
+	assert.NoError(t, err)
+	successReq.Header.Set("Content-Type", successW.FormDataContentType())
+
+	notSquareReq, notSquareW, err := createReq(notSquarePath, testURL+"?degrees=90")
+	assert.NoError(t, err)
+	notSquareReq.Header.Set("Content-Type", notSquareW.FormDataContentType())
+
+	invalidReq, invalidW, err := createReq(validPath, testURL+"?degrees=45")
+	assert.NoError(t, err)
+	invalidReq.Header.Set("Content-Type", invalidW.FormDataContentType())
+
+	tt := []struct {
+		name         string
+		providedReq  *http.Request
+		expectedBody string
+		expectedCode int
+	}{
+		{
+			name:         "fail: invalid degrees - BadRequest",
+			providedReq:  invalidReq,
+			expectedBody: errInvalidDegrees.Error() + "\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "success: rotated",
+			providedReq:  successReq,
+			expectedBody: "7,4,1\n8,5,2\n9,6,3\n",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "success: not square matrix rotated",
+			providedReq:  notSquareReq,
+			expectedBody: "4,1\n5,2\n6,3\n",
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.Rotate(w, tc.providedReq)
+
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestRouter_Flip(t *testing.T) {
+	router, err := setupRouter()
+	assert.NoError(t, err)
+
+	successReq, successW, err := createReq(validPath, testURL+"?direction=vertical")
+	assert.NoError(t, err)
+	successReq.Header.Set("Content-Type", successW.FormDataContentType())
+
+	notSquareReq, notSquareW, err := createReq(notSquarePath, testURL+"?direction=vertical")
+	assert.NoError(t, err)
+	notSquareReq.Header.Set("Content-Type", notSquareW.FormDataContentType())
+
+	invalidReq, invalidW, err := createReq(validPath, testURL+"?direction=diagonal")
+	assert.NoError(t, err)
+	invalidReq.Header.Set("Content-Type", invalidW.FormDataContentType())
+
+	tt := []struct {
+		name         string
+		providedReq  *http.Request
+		expectedBody string
+		expectedCode int
+	}{
+		{
+			name:         "fail: invalid direction - BadRequest",
+			providedReq:  invalidReq,
+			expectedBody: errInvalidDirection.Error() + "\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "success: flipped",
+			providedReq:  successReq,
+			expectedBody: "7,8,9\n4,5,6\n1,2,3\n",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "success: not square matrix flipped",
+			providedReq:  notSquareReq,
+			expectedBody: "4,5,6\n1,2,3\n",
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.Flip(w, tc.providedReq)
+
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestRouter_Triangle(t *testing.T) {
+	router, err := setupRouter()
+	assert.NoError(t, err)
+
+	successReq, successW, err := createReq(validPath, testURL+"?part=lower")
+	assert.NoError(t, err)
+	successReq.Header.Set("Content-Type", successW.FormDataContentType())
+
+	fillReq, fillW, err := createReq(validPath, testURL+"?part=upper&fill=")
+	assert.NoError(t, err)
+	fillReq.Header.Set("Content-Type", fillW.FormDataContentType())
+
+	notSquareReq, notSquareW, err := createReq(notSquarePath, testURL+"?part=lower")
+	assert.NoError(t, err)
+	notSquareReq.Header.Set("Content-Type", notSquareW.FormDataContentType())
+
+	invalidReq, invalidW, err := createReq(validPath, testURL+"?part=middle")
+	assert.NoError(t, err)
+	invalidReq.Header.Set("Content-Type", invalidW.FormDataContentType())
+
+	tt := []struct {
+		name         string
+		providedReq  *http.Request
+		expectedBody string
+		expectedCode int
+	}{
+		{
+			name:         "fail: invalid part - BadRequest",
+			providedReq:  invalidReq,
+			expectedBody: errInvalidPart.Error() + "\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "success: lower triangle with default fill",
+			providedReq:  successReq,
+			expectedBody: "1,0,0\n4,5,0\n7,8,9\n",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "success: upper triangle with empty fill",
+			providedReq:  fillReq,
+			expectedBody: "1,2,3\n,5,6\n,,9\n",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "success: lower triangle of not square matrix",
+			providedReq:  notSquareReq,
+			expectedBody: "1,0,0\n4,5,0\n",
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.Triangle(w, tc.providedReq)
+
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestRouter_diagonals(t *testing.T) {
+	router, err := setupRouter()
+	assert.NoError(t, err)
+
+	tt := []struct {
+		name            string
+		providedHandler http.HandlerFunc
+		expectedBody    string
+	}{
+		{
+			name:            "success: anti-transposed not square matrix",
+			providedHandler: router.AntiTranspose,
+			expectedBody:    "6,3\n5,2\n4,1\n",
+		},
+		{
+			name:            "success: diagonal of not square matrix",
+			providedHandler: router.Diagonal,
+			expectedBody:    "1,5\n",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			req, reqW, err := createReq(notSquarePath, testURL)
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", reqW.FormDataContentType())
+			w := httptest.NewRecorder()
+			tc.providedHandler(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// INFO: server listens on ADDR (":8080" by default) and serves HTTPS when both TLS_CERT_FILE and
+	// TLS_KEY_FILE are set. Client certificates are verified against TLS_CLIENT_CA_FILE bundle, they are
+	// required unless TLS_CLIENT_AUTH=optional. HTTP/2 is negotiated over TLS unless HTTP2=false.
+	addrEnv          = "ADDR"
+	tlsCertFileEnv   = "TLS_CERT_FILE"
+	tlsKeyFileEnv    = "TLS_KEY_FILE"
+	tlsClientCAEnv   = "TLS_CLIENT_CA_FILE"
+	tlsClientAuthEnv = "TLS_CLIENT_AUTH"
+	http2Env         = "HTTP2"
+
+	clientAuthRequire  = "require"
+	clientAuthOptional = "optional"
+
+	// INFO: certificate files are checked for changes not more often than this.
+	certCheckInterval = 10 * time.Second
+	readHeaderTimeout = 10 * time.Second
+)
+
+var (
+	errTLSPair      = errors.New("both TLS_CERT_FILE and TLS_KEY_FILE should be set")
+	errClientCA     = errors.New("client CA bundle doesn't contain any PEM certificate")
+	errClientAuth   = errors.New("invalid TLS_CLIENT_AUTH, should be one of \"require\", \"optional\"")
+	errClientCAOnly = errors.New("TLS_CLIENT_CA_FILE requires TLS_CERT_FILE and TLS_KEY_FILE")
+)
+
+type serverConfig struct {
+	addr         string
+	certFile     string
+	keyFile      string
+	clientCAFile string
+	clientAuth   tls.ClientAuthType
+	http2        bool
+}
+
+func (c serverConfig) tlsEnabled() bool {
+	return c.certFile != ""
+}
+
+func serverConfigFromEnv(e env) (serverConfig, error) {
+	cfg := serverConfig{
+		addr:         net.JoinHostPort(host, port),
+		certFile:     e(tlsCertFileEnv),
+		keyFile:      e(tlsKeyFileEnv),
+		clientCAFile: e(tlsClientCAEnv),
+		clientAuth:   tls.RequireAndVerifyClientCert,
+		http2:        true,
+	}
+	if addr := e(addrEnv); addr != "" {
+		cfg.addr = addr
+	}
+	if (cfg.certFile == "") != (cfg.keyFile == "") {
+		return serverConfig{}, fmt.Errorf("%w: %s", errInvalidConfig, errTLSPair.Error())
+	}
+	if cfg.clientCAFile != "" && !cfg.tlsEnabled() {
+		return serverConfig{}, fmt.Errorf("%w: %s", errInvalidConfig, errClientCAOnly.Error())
+	}
+	switch e(tlsClientAuthEnv) {
+	case "", clientAuthRequire:
+	case clientAuthOptional:
+		cfg.clientAuth = tls.VerifyClientCertIfGiven
+	default:
+		return serverConfig{}, fmt.Errorf("%w: %s", errInvalidConfig, errClientAuth.Error())
+	}
+	if raw := e(http2Env); raw != "" {
+		enabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			return serverConfig{}, fmt.Errorf("%w: %s should be boolean, got %q", errInvalidConfig, http2Env, raw)
+		}
+		cfg.http2 = enabled
+	}
+	return cfg, nil
+}
+
+// INFO: certReloader serves certificate from files and reloads it when modification time of any file changes,
+// so renewed certificates are picked up without restart. Broken files are reported and the previous
+// certificate is used.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	log      *zap.Logger
+	interval time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	certMod time.Time
+	keyMod  time.Time
+	checked time.Time
+}
+
+func newCertReloader(certFile string, keyFile string, log *zap.Logger) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile, log: log, interval: certCheckInterval, now: time.Now}
+	certMod, keyMod, err := r.modTimes()
+	if err != nil {
+		return nil, err
+	}
+	err = r.load(certMod, keyMod)
+	if err != nil {
+		return nil, err
+	}
+	r.checked = r.now()
+	return r, nil
+}
+
+func (r *certReloader) modTimes() (time.Time, time.Time, error) {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
+
+func (r *certReloader) load(certMod time.Time, keyMod time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert, r.certMod, r.keyMod = &cert, certMod, keyMod
+	return nil
+}
+
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now := r.now(); now.Sub(r.checked) >= r.interval {
+		r.checked = now
+		certMod, keyMod, err := r.modTimes()
+		if err == nil && (!certMod.Equal(r.certMod) || !keyMod.Equal(r.keyMod)) {
+			err = r.load(certMod, keyMod)
+			if err == nil {
+				r.log.Info("TLS certificate reloaded", zap.String("cert", r.certFile))
+			}
+		}
+		if err != nil {
+			r.log.Error("reloading TLS certificate failed, previous one is used", zap.Error(err))
+		}
+	}
+	return r.cert, nil
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(content) {
+		return nil, errClientCA
+	}
+	return pool, nil
+}
+
+// INFO: builds server with TLS configuration when it is enabled. HTTP/2 is negotiated by net/http through
+// ALPN, it is disabled with empty TLSNextProto map.
+func newServer(cfg serverConfig, handler http.Handler, log *zap.Logger) (*http.Server, error) {
+	srv := &http.Server{
+		Addr:              cfg.addr,
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ErrorLog:          zap.NewStdLog(log),
+	}
+	if !cfg.http2 {
+		srv.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+	}
+	if !cfg.tlsEnabled() {
+		return srv, nil
+	}
+
+	reloader, err := newCertReloader(cfg.certFile, cfg.keyFile, log)
+	if err != nil {
+		return nil, err
+	}
+	srv.TLSConfig = &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.getCertificate,
+	}
+	if cfg.clientCAFile != "" {
+		pool, err := loadCertPool(cfg.clientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		srv.TLSConfig.ClientCAs = pool
+		srv.TLSConfig.ClientAuth = cfg.clientAuth
+	}
+	return srv, nil
+}
+
+// INFO: serves plain HTTP or HTTPS depending on configuration, certificates are taken from TLSConfig.
+func serve(srv *http.Server, cfg serverConfig) error {
+	if cfg.tlsEnabled() {
+		return srv.ListenAndServeTLS("", "")
+	}
+	return srv.ListenAndServe()
+}
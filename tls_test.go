@@ -0,0 +1,269 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// INFO: issues certificate signed by parent, self-signed one is created when parent is nil.
+func issueCert(t *testing.T, name string, parent *testCert, isCA bool) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{cert: cert, key: key}
+}
+
+func (c *testCert) write(t *testing.T, dir string, name string) (string, string) {
+	t.Helper()
+	certPath, keyPath := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0o600))
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certPath, keyPath
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key}
+}
+
+func Test_serverConfigFromEnv(t *testing.T) {
+	tt := []struct {
+		name        string
+		providedEnv map[string]string
+		expectedCfg serverConfig
+		expectedErr error
+	}{
+		{
+			name:        "fail: key file is missing",
+			providedEnv: map[string]string{tlsCertFileEnv: "server.crt"},
+			expectedErr: errInvalidConfig,
+		},
+		{
+			name:        "fail: client CA without TLS",
+			providedEnv: map[string]string{tlsClientCAEnv: "ca.crt"},
+			expectedErr: errInvalidConfig,
+		},
+		{
+			name:        "fail: unknown client auth",
+			providedEnv: map[string]string{tlsClientAuthEnv: "sometimes"},
+			expectedErr: errInvalidConfig,
+		},
+		{
+			name:        "fail: invalid HTTP2 flag",
+			providedEnv: map[string]string{http2Env: "maybe"},
+			expectedErr: errInvalidConfig,
+		},
+		{
+			name:        "success: plain HTTP by default",
+			providedEnv: map[string]string{},
+			expectedCfg: serverConfig{addr: ":8080", clientAuth: tls.RequireAndVerifyClientCert, http2: true},
+		},
+		{
+			name: "success: mTLS with optional client certificates",
+			providedEnv: map[string]string{
+				addrEnv:          "127.0.0.1:8443",
+				tlsCertFileEnv:   "server.crt",
+				tlsKeyFileEnv:    "server.key",
+				tlsClientCAEnv:   "ca.crt",
+				tlsClientAuthEnv: clientAuthOptional,
+				http2Env:         "false",
+			},
+			expectedCfg: serverConfig{
+				addr:         "127.0.0.1:8443",
+				certFile:     "server.crt",
+				keyFile:      "server.key",
+				clientCAFile: "ca.crt",
+				clientAuth:   tls.VerifyClientCertIfGiven,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := serverConfigFromEnv(func(key string) string {
+				return tc.providedEnv[key]
+			})
+			assert.ErrorIs(t, err, tc.expectedErr)
+			assert.Equal(t, tc.expectedCfg, res)
+		})
+	}
+}
+
+func Test_certReloader(t *testing.T) {
+	dir := t.TempDir()
+	ca := issueCert(t, "ca", nil, true)
+	first, second := issueCert(t, "first", ca, false), issueCert(t, "second", ca, false)
+	certPath, keyPath := first.write(t, dir, "server")
+
+	_, err := newCertReloader(filepath.Join(dir, "missing.crt"), keyPath, zap.NewNop())
+	assert.Error(t, err)
+
+	reloader, err := newCertReloader(certPath, keyPath, zap.NewNop())
+	require.NoError(t, err)
+	now := time.Now()
+	reloader.now = func() time.Time { return now }
+
+	current := func() string {
+		cert, err := reloader.getCertificate(nil)
+		require.NoError(t, err)
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, err)
+		return leaf.Subject.CommonName
+	}
+	touch := func(mod time.Time) {
+		require.NoError(t, os.Chtimes(certPath, mod, mod))
+		require.NoError(t, os.Chtimes(keyPath, mod, mod))
+	}
+
+	second.write(t, dir, "server")
+	touch(now.Add(time.Minute))
+	assert.Equal(t, "first", current(), "files aren't checked before interval passes")
+
+	now = now.Add(certCheckInterval)
+	assert.Equal(t, "second", current())
+
+	require.NoError(t, os.WriteFile(keyPath, []byte("broken"), 0o600))
+	touch(now.Add(2 * time.Minute))
+	now = now.Add(certCheckInterval)
+	assert.Equal(t, "second", current(), "previous certificate is kept when files are broken")
+}
+
+func TestServer_tls(t *testing.T) {
+	dir := t.TempDir()
+	ca := issueCert(t, "ca", nil, true)
+	certPath, keyPath := issueCert(t, "server", ca, false).write(t, dir, "server")
+	caPath, _ := ca.write(t, dir, "ca")
+	client := issueCert(t, "client", ca, false)
+	stranger := issueCert(t, "stranger", issueCert(t, "other-ca", nil, true), false)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	tt := []struct {
+		name              string
+		providedAuth      tls.ClientAuthType
+		providedHTTP2     bool
+		providedCert      *testCert
+		expectedProto     string
+		expectedHandshake bool
+	}{
+		{
+			name:          "fail: client certificate is required",
+			providedAuth:  tls.RequireAndVerifyClientCert,
+			providedHTTP2: true,
+		},
+		{
+			name:          "fail: client certificate from unknown CA",
+			providedAuth:  tls.VerifyClientCertIfGiven,
+			providedHTTP2: true,
+			providedCert:  stranger,
+		},
+		{
+			name:              "success: verified client over HTTP/2",
+			providedAuth:      tls.RequireAndVerifyClientCert,
+			providedHTTP2:     true,
+			providedCert:      client,
+			expectedProto:     "HTTP/2.0",
+			expectedHandshake: true,
+		},
+		{
+			name:              "success: optional client certificate over HTTP/1.1",
+			providedAuth:      tls.VerifyClientCertIfGiven,
+			expectedProto:     "HTTP/1.1",
+			expectedHandshake: true,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := serverConfig{
+				certFile:     certPath,
+				keyFile:      keyPath,
+				clientCAFile: caPath,
+				clientAuth:   tc.providedAuth,
+				http2:        tc.providedHTTP2,
+			}
+			srv, err := newServer(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}), zap.NewNop())
+			require.NoError(t, err)
+
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			go func() {
+				_ = srv.ServeTLS(ln, "", "")
+			}()
+			defer srv.Close()
+
+			clientCfg := &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+			if tc.providedCert != nil {
+				// INFO: certificate is presented even if its issuer isn't among CAs accepted by server.
+				cert := tc.providedCert.tlsCertificate()
+				clientCfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+					return &cert, nil
+				}
+			}
+			httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: clientCfg, ForceAttemptHTTP2: true}}
+			defer httpClient.CloseIdleConnections()
+
+			resp, err := httpClient.Get("https://" + ln.Addr().String() + "/")
+			if !tc.expectedHandshake {
+				var netErr net.Error
+				assert.Error(t, err)
+				assert.False(t, errors.As(err, &netErr) && netErr.Timeout())
+				return
+			}
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, tc.expectedProto, resp.Proto)
+		})
+	}
+}